@@ -2,4 +2,8 @@ module dev.getsol.us/source/libypkg.git
 
 go 1.15
 
-require gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
+require (
+	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
+	mvdan.cc/sh/v3 v3.3.1
+)
@@ -0,0 +1,138 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package lsp
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// subpackages are the names ypkg commonly uses for subpackages
+var subpackages = []string{"32bit", "32bit-devel", "demos", "devel", "docs", "libs", "utils"}
+
+// subpackagePattern finds the subpackages already used in a document
+var subpackagePattern = regexp.MustCompile(`(?m)^\s*-\s+([A-Za-z0-9+._-]+)\s*:\s*$`)
+
+// listMaps are the keys whose entries may be grouped by subpackage
+var listMaps = map[string]bool{
+	"patterns":  true,
+	"permanent": true,
+	"rundeps":   true,
+	"replaces":  true,
+	"conflicts": true,
+	"run":       true,
+}
+
+// items turns a list of labels into CompletionItems of a single kind
+func items(labels []string, kind CompletionItemKind, suffix string) (cs []CompletionItem) {
+	for _, label := range labels {
+		cs = append(cs, CompletionItem{
+			Label:      label,
+			Kind:       kind,
+			InsertText: label + suffix,
+		})
+	}
+	return
+}
+
+// complete suggests keys, flag values, macros or subpackages for a Position in a document
+func complete(d *document, pos Position) []CompletionItem {
+	line := d.line(pos.Line)
+	prefix := line[:offset(line, pos.Character)]
+	word := prefix[strings.LastIndexAny(prefix, " \t")+1:]
+	indent := indentation(prefix)
+	if len(strings.TrimSpace(prefix)) == 0 {
+		indent = len(prefix)
+	}
+	parents := d.parents(pos.Line, indent)
+	current, _, hasKey := key(prefix)
+	var top string
+	if len(parents) > 0 {
+		top = parents[0]
+	}
+	switch {
+	case strings.HasPrefix(word, "%") && spec.IsStage(d.ypkg, top):
+		return macros()
+	case len(parents) == 0 && !hasKey:
+		return keyItems(d.ypkg, "")
+	case hasKey && current == "optimize", len(parents) > 0 && parents[len(parents)-1] == "optimize":
+		return items(spec.Optimizations, KindValue, "")
+	case len(parents) == 1 && !hasKey && len(spec.Keys(d.ypkg, top)) > 0:
+		return keyItems(d.ypkg, top)
+	case len(parents) > 0 && listMaps[parents[len(parents)-1]] && strings.HasPrefix(strings.TrimSpace(prefix), "-"):
+		return items(d.subpackages(), KindProperty, ":")
+	}
+	return []CompletionItem{}
+}
+
+// macros suggests every macro in the Catalog, with the description of each Action
+func macros() (cs []CompletionItem) {
+	c := spec.Macros()
+	for _, name := range c.Names() {
+		item := CompletionItem{
+			Label:      name,
+			Kind:       KindSnippet,
+			InsertText: name,
+		}
+		if action, ok := c.Actions[strings.TrimPrefix(name, "%")]; ok {
+			item.Detail = action.Description
+			item.Documentation = action.Script
+		}
+		cs = append(cs, item)
+	}
+	return
+}
+
+// keyItems suggests every key of a mapping for the version of the document
+func keyItems(ypkg int, path string) (cs []CompletionItem) {
+	for _, name := range spec.Keys(ypkg, path) {
+		cs = append(cs, CompletionItem{
+			Label:         name,
+			Kind:          KindProperty,
+			Documentation: fieldDoc(ypkg, join(path, name)),
+			InsertText:    name + ": ",
+		})
+	}
+	return
+}
+
+// join adds a key to a dotted path
+func join(path, name string) string {
+	if len(path) == 0 {
+		return name
+	}
+	return path + "." + name
+}
+
+// subpackages gets the common subpackage names, and any others already used in the document
+func (d *document) subpackages() []string {
+	names := make(map[string]bool)
+	for _, name := range subpackages {
+		names[name] = true
+	}
+	for _, match := range subpackagePattern.FindAllStringSubmatch(d.text, -1) {
+		names[match[1]] = true
+	}
+	var all []string
+	for name := range names {
+		all = append(all, name)
+	}
+	sort.Strings(all)
+	return all
+}
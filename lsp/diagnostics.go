@@ -0,0 +1,154 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package lsp
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// errorLine finds the line number in the message of a YAML error
+var errorLine = regexp.MustCompile(`line (\d+)`)
+
+// v2Fields maps the fields of internal.PackageYML to the keys used by the v2 format, where they differ
+var v2Fields = map[string]string{
+	"deps.build":     "builddeps",
+	"deps.check":     "builddeps",
+	"deps.run":       "rundeps",
+	"deps.replaces":  "replaces",
+	"deps.conflicts": "conflicts",
+	"components":     "component",
+	"summaries":      "summary",
+	"descriptions":   "description",
+}
+
+// lineRange gets the Range covering the text of a whole line
+func (d *document) lineRange(i int) Range {
+	line := d.line(i)
+	return Range{
+		Start: Position{Line: i, Character: indentation(line)},
+		End:   Position{Line: i, Character: character(line, len(line))},
+	}
+}
+
+// find gets the key and value nodes for a dotted path of keys
+func find(root *yaml.Node, field string) (k, v *yaml.Node) {
+	node := root
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, part := range strings.Split(field, ".") {
+		if node.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		var found bool
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				k, v = node.Content[i], node.Content[i+1]
+				node = v
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil
+		}
+	}
+	return
+}
+
+// locate finds the Range of a line within the value of a field
+//
+// Problems with missing fields are shown on the first line.
+func (d *document) locate(root *yaml.Node, field string, offset int) Range {
+	if d.ypkg == 2 {
+		if alias, ok := v2Fields[field]; ok {
+			field = alias
+		} else {
+			field = strings.TrimPrefix(field, "flags.")
+		}
+	}
+	k, v := find(root, field)
+	if k == nil {
+		return d.lineRange(0)
+	}
+	line := k.Line - 1
+	if offset > 0 && v != nil {
+		switch {
+		case v.Kind == yaml.SequenceNode && offset <= len(v.Content):
+			line = v.Content[offset-1].Line - 1
+		case v.Kind == yaml.ScalarNode && v.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0:
+			line = v.Line - 1 + offset
+		}
+	}
+	return d.lineRange(line)
+}
+
+// failure gets a Diagnostic for a document which could not be read at all
+func (d *document) failure(err error) Diagnostic {
+	line := 0
+	if match := errorLine.FindStringSubmatch(err.Error()); match != nil {
+		line, _ = strconv.Atoi(match[1])
+		line--
+	}
+	return Diagnostic{
+		Range:    d.lineRange(line),
+		Severity: SeverityError,
+		Source:   "ypkg",
+		Message:  err.Error(),
+	}
+}
+
+// diagnose loads a document and lints it, finding the line for each Problem
+func diagnose(d *document) []Diagnostic {
+	ds := make([]Diagnostic, 0)
+	pkg, err := spec.Parse([]byte(d.text))
+	if err != nil {
+		return append(ds, d.failure(err))
+	}
+	i, err := pkg.Convert()
+	if err != nil {
+		return append(ds, d.failure(err))
+	}
+	err = i.Lint()
+	if err == nil {
+		return ds
+	}
+	ps, ok := err.(spec.Problems)
+	if !ok {
+		return append(ds, d.failure(err))
+	}
+	var root yaml.Node
+	_ = yaml.Unmarshal([]byte(d.text), &root)
+	for _, p := range ps {
+		severity := SeverityError
+		if p.Severity == spec.SeverityWarning {
+			severity = SeverityWarning
+		}
+		ds = append(ds, Diagnostic{
+			Range:    d.locate(&root, p.Field, p.Line),
+			Severity: severity,
+			Code:     p.Rule,
+			Source:   "ypkg",
+			Message:  p.Message,
+		})
+	}
+	return ds
+}
@@ -0,0 +1,117 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package lsp
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+var (
+	// versionPattern finds the version of the format in a document
+	versionPattern = regexp.MustCompile(`(?m)^YPKG\s*:\s*["']?(\d+)`)
+	// keyPattern finds a mapping key at the start of a line, including list items like "- devel:"
+	keyPattern = regexp.MustCompile(`^(\s*)(?:-\s+)?([A-Za-z0-9_.+^-]+)\s*:(?:\s|$)`)
+)
+
+// document is the text of a package.yml open in an editor
+type document struct {
+	text  string
+	lines []string
+	ypkg  int
+}
+
+// newDocument splits the text of a document into lines and finds its version
+func newDocument(text string) *document {
+	d := &document{
+		text:  text,
+		lines: strings.Split(text, "\n"),
+		ypkg:  2,
+	}
+	if match := versionPattern.FindStringSubmatch(text); match != nil {
+		d.ypkg, _ = strconv.Atoi(match[1])
+	}
+	return d
+}
+
+// line gets a single line of the document, or an empty string if it is out of range
+func (d *document) line(i int) string {
+	if i < 0 || i >= len(d.lines) {
+		return ""
+	}
+	return d.lines[i]
+}
+
+// offset converts a Position.Character, counted in UTF-16 code units, to a byte offset within a line
+//
+// Characters outside of the line are clamped to its start or end.
+func offset(line string, character int) int {
+	var units int
+	for i, r := range line {
+		if units >= character {
+			return i
+		}
+		units++
+		if r >= 0x10000 {
+			units++
+		}
+	}
+	return len(line)
+}
+
+// character converts a byte offset within a line to a Position.Character, counted in UTF-16 code units
+func character(line string, offset int) int {
+	return len(utf16.Encode([]rune(line[:offset])))
+}
+
+// key finds the mapping key on a line, with its indentation
+func key(line string) (name string, indent int, ok bool) {
+	match := keyPattern.FindStringSubmatch(line)
+	if match == nil {
+		return
+	}
+	return match[2], len(match[1]), true
+}
+
+// indentation counts the leading spaces of a line
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// parents finds the keys of the mappings which contain a line indented by "limit", from the top-level down
+//
+// Block scalars, like stage scripts, count as the mapping of their key.
+func (d *document) parents(i, limit int) (path []string) {
+	for j := i - 1; j >= 0 && limit > 0; j-- {
+		line := d.lines[j]
+		if len(strings.TrimSpace(line)) == 0 || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		name, indent, ok := key(line)
+		if !ok || indent >= limit {
+			if !ok && indentation(line) < limit {
+				limit = indentation(line)
+			}
+			continue
+		}
+		path = append([]string{name}, path...)
+		limit = indent
+	}
+	return
+}
@@ -0,0 +1,77 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package lsp
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec"
+	"fmt"
+	"strings"
+)
+
+// fieldDoc gets the description of a key in any version of the format
+func fieldDoc(ypkg int, path string) string {
+	f, err := spec.Explain(ypkg, path)
+	if err != nil {
+		return ""
+	}
+	return f.Description
+}
+
+// markdown formats the documentation of a Field for display in an editor
+func markdown(f spec.Field) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s**: %s\n\n%s\n", f.Path, f.Type, f.Description)
+	if len(f.Default) > 0 {
+		fmt.Fprintf(&b, "\nDefault: `%s`\n", f.Default)
+	}
+	if len(f.Values) > 0 {
+		fmt.Fprintf(&b, "\nValues: `%s`\n", strings.Join(f.Values, "`, `"))
+	}
+	if len(f.Example) > 0 {
+		fmt.Fprintf(&b, "\n```yaml\n%s: %s\n```\n", f.Name(), f.Example)
+	}
+	return b.String()
+}
+
+// hover finds the documentation for the key under the cursor
+func hover(d *document, pos Position) *Hover {
+	line := d.line(pos.Line)
+	name, indent, ok := key(line)
+	if !ok {
+		return nil
+	}
+	start := indent + strings.Index(line[indent:], name)
+	end := start + len(name)
+	if at := offset(line, pos.Character); pos.Character < 0 || at < start || at > end {
+		return nil
+	}
+	path := strings.Join(append(d.parents(pos.Line, indent), name), ".")
+	f, err := spec.Explain(d.ypkg, path)
+	if err != nil || len(f.Description) == 0 {
+		return nil
+	}
+	return &Hover{
+		Contents: MarkupContent{
+			Kind:  "markdown",
+			Value: markdown(f),
+		},
+		Range: &Range{
+			Start: Position{Line: pos.Line, Character: character(line, start)},
+			End:   Position{Line: pos.Line, Character: character(line, end)},
+		},
+	}
+}
@@ -0,0 +1,190 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrExitWithoutShutdown indicates that the client asked the Server to exit before shutting it down
+var ErrExitWithoutShutdown = errors.New("exit received before shutdown")
+
+// Server is a language server for package.yml, speaking JSON-RPC over a pair of streams
+type Server struct {
+	in       *bufio.Reader
+	out      io.Writer
+	docs     map[string]*document
+	shutdown bool
+}
+
+// NewServer creates a Server which reads requests from "in" and writes responses to "out"
+func NewServer(in io.Reader, out io.Writer) *Server {
+	return &Server{
+		in:   bufio.NewReader(in),
+		out:  out,
+		docs: make(map[string]*document),
+	}
+}
+
+// Serve handles messages until the client exits or the input is closed
+func (s *Server) Serve() error {
+	for {
+		body, err := readMessage(s.in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var req request
+		if err = json.Unmarshal(body, &req); err != nil {
+			if err = s.fail(nil, codeParseError, err.Error()); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+		if err = s.handle(req); err != nil {
+			return err
+		}
+	}
+}
+
+// handle dispatches a single request or notification
+func (s *Server) handle(req request) error {
+	var result interface{}
+	var err error
+	switch req.Method {
+	case "initialize":
+		result = s.initialize()
+	case "initialized":
+		return nil
+	case "shutdown":
+		s.shutdown = true
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			return s.update(params.TextDocument.URI, params.TextDocument.Text)
+		}
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			if n := len(params.ContentChanges); n > 0 {
+				return s.update(params.TextDocument.URI, params.ContentChanges[n-1].Text)
+			}
+			return nil
+		}
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			delete(s.docs, params.TextDocument.URI)
+			return s.publish(params.TextDocument.URI, make([]Diagnostic, 0))
+		}
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			result = make([]CompletionItem, 0)
+			if d, ok := s.docs[params.TextDocument.URI]; ok {
+				result = complete(d, params.Position)
+			}
+		}
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err = json.Unmarshal(req.Params, &params); err == nil {
+			if d, ok := s.docs[params.TextDocument.URI]; ok {
+				if h := hover(d, params.Position); h != nil {
+					result = h
+				}
+			}
+		}
+	default:
+		// Unknown notifications are ignored, but requests must always get a response
+		if req.ID == nil {
+			return nil
+		}
+		return s.fail(req.ID, codeMethodNotFound, "method not found: "+req.Method)
+	}
+	if err != nil {
+		if req.ID == nil {
+			return nil
+		}
+		return s.fail(req.ID, codeInvalidParams, err.Error())
+	}
+	if req.ID == nil {
+		return nil
+	}
+	return writeMessage(s.out, response{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+		Result:  result,
+	})
+}
+
+// initialize describes the capabilities of this Server
+func (s *Server) initialize() interface{} {
+	return map[string]interface{}{
+		"capabilities": map[string]interface{}{
+			"textDocumentSync": 1,
+			"completionProvider": map[string]interface{}{
+				"triggerCharacters": []string{"%", "-", " "},
+			},
+			"hoverProvider": true,
+		},
+		"serverInfo": map[string]string{
+			"name": "ypkg",
+		},
+	}
+}
+
+// update replaces the contents of a document and publishes its Diagnostics
+func (s *Server) update(URI, text string) error {
+	d := newDocument(text)
+	s.docs[URI] = d
+	return s.publish(URI, diagnose(d))
+}
+
+// publish sends the Diagnostics for a document to the client
+func (s *Server) publish(URI string, ds []Diagnostic) error {
+	return writeMessage(s.out, notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params: PublishDiagnosticsParams{
+			URI:         URI,
+			Diagnostics: ds,
+		},
+	})
+}
+
+// fail sends an error response for a request
+func (s *Server) fail(ID *json.RawMessage, code int, message string) error {
+	return writeMessage(s.out, errorResponse{
+		JSONRPC: "2.0",
+		ID:      ID,
+		Error: &ResponseError{
+			Code:    code,
+			Message: message,
+		},
+	})
+}
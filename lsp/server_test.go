@@ -0,0 +1,285 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testURI = "file:///packages/foo/package.yml"
+
+const testV3 = `YPKG: 3
+name: foo
+version: 1.0@rc1
+release: 1
+source:
+  - https://example.com/foo-1.0.tar.gz : 0123456789abcdef
+license: MIT
+component: system.utils
+summary: Foo
+description: Foo does things
+flags:
+  clang: no
+  optimize:
+    - speed
+setup: |
+  %configure
+install: |
+  %make_install
+patterns:
+  - devel:
+    - /usr/include
+`
+
+// message is any outgoing message from the Server
+type message struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+	Result json.RawMessage  `json:"result"`
+	Error  *ResponseError   `json:"error"`
+}
+
+// session runs a Server over a list of requests, ending with a shutdown and exit
+func session(t *testing.T, reqs ...string) (msgs []message) {
+	var in, out bytes.Buffer
+	reqs = append(reqs, `{"jsonrpc":"2.0","id":99,"method":"shutdown"}`, `{"jsonrpc":"2.0","method":"exit"}`)
+	for _, req := range reqs {
+		fmt.Fprintf(&in, "Content-Length: %d\r\n\r\n%s", len(req), req)
+	}
+	if err := NewServer(&in, &out).Serve(); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	r := bufio.NewReader(&out)
+	for {
+		body, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		var msg message
+		if err = json.Unmarshal(body, &msg); err != nil {
+			t.Fatalf("Expected no error, found: %s", err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return
+}
+
+// open builds a didOpen notification for the test document
+func open(text string) string {
+	params, _ := json.Marshal(DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: testURI, Version: 1, Text: text},
+	})
+	return fmt.Sprintf(`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":%s}`, params)
+}
+
+// at builds a request for a Position in the test document
+func at(method string, line, character int) string {
+	params, _ := json.Marshal(TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: testURI},
+		Position:     Position{Line: line, Character: character},
+	})
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"%s","params":%s}`, method, params)
+}
+
+// labels completes at a Position in a document and gets the label of each item
+func labels(t *testing.T, text string, line, character int) map[string]bool {
+	msgs := session(t, open(text), at("textDocument/completion", line, character))
+	var cs []CompletionItem
+	if err := json.Unmarshal(msgs[1].Result, &cs); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	found := make(map[string]bool)
+	for _, c := range cs {
+		found[c.Label] = true
+	}
+	return found
+}
+
+func TestDiagnostics(t *testing.T) {
+	text := strings.Replace(testV3, "summary: Foo\n", "", 1)
+	msgs := session(t, open(text))
+	if msgs[0].Method != "textDocument/publishDiagnostics" {
+		t.Fatalf("expected '%s', found: %s", "textDocument/publishDiagnostics", msgs[0].Method)
+	}
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(msgs[0].Params, &params); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	expected := map[string]int{
+		"required-field":  0,
+		"invalid-version": 2,
+	}
+	if len(params.Diagnostics) != len(expected) {
+		t.Fatalf("expected %d diagnostics, found: %v", len(expected), params.Diagnostics)
+	}
+	for _, d := range params.Diagnostics {
+		line, ok := expected[d.Code]
+		if !ok {
+			t.Errorf("unexpected diagnostic: %v", d)
+			continue
+		}
+		if d.Range.Start.Line != line {
+			t.Errorf("expected '%s' on line %d, found: %d", d.Code, line, d.Range.Start.Line)
+		}
+	}
+}
+
+func TestDiagnosticsMacro(t *testing.T) {
+	msgs := session(t, open(strings.Replace(testV3, "%configure", "%confgure", 1)))
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(msgs[0].Params, &params); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	found := false
+	for _, d := range params.Diagnostics {
+		if d.Code == "unknown-macro" {
+			found = true
+			if d.Range.Start.Line != 15 {
+				t.Errorf("expected '%s' on line %d, found: %d", d.Code, 15, d.Range.Start.Line)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected '%s', found: %v", "unknown-macro", params.Diagnostics)
+	}
+}
+
+func TestDiagnosticsInvalidYAML(t *testing.T) {
+	msgs := session(t, open("name: foo\nversion: [1.0\n"))
+	var params PublishDiagnosticsParams
+	if err := json.Unmarshal(msgs[0].Params, &params); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if len(params.Diagnostics) != 1 || params.Diagnostics[0].Severity != SeverityError {
+		t.Fatalf("expected a single error, found: %v", params.Diagnostics)
+	}
+}
+
+func TestCompleteKeys(t *testing.T) {
+	v3 := labels(t, testV3+"\n", 21, 0)
+	for _, key := range []string{"deps", "flags", "homepage"} {
+		if !v3[key] {
+			t.Errorf("expected '%s' for v3, found: %v", key, v3)
+		}
+	}
+	if v3["builddeps"] {
+		t.Errorf("expected no '%s' for v3", "builddeps")
+	}
+	v2 := labels(t, "name: foo\n\n", 1, 0)
+	for _, key := range []string{"builddeps", "rundeps", "clang", "optimize"} {
+		if !v2[key] {
+			t.Errorf("expected '%s' for v2, found: %v", key, v2)
+		}
+	}
+	if v2["deps"] {
+		t.Errorf("expected no '%s' for v2", "deps")
+	}
+}
+
+func TestCompleteFlags(t *testing.T) {
+	flags := labels(t, strings.Replace(testV3, "  clang: no\n", "  clang: no\n  \n", 1), 12, 2)
+	for _, key := range []string{"clang", "optimize", "networking"} {
+		if !flags[key] {
+			t.Errorf("expected '%s', found: %v", key, flags)
+		}
+	}
+	values := labels(t, testV3, 13, 6)
+	for _, value := range []string{"speed", "lto"} {
+		if !values[value] {
+			t.Errorf("expected '%s', found: %v", value, values)
+		}
+	}
+}
+
+func TestCompleteMacros(t *testing.T) {
+	macros := labels(t, strings.Replace(testV3, "  %configure\n", "  %con\n", 1), 15, 6)
+	for _, name := range []string{"%configure", "%apply_patches", "%libsuffix%"} {
+		if !macros[name] {
+			t.Errorf("expected '%s', found: %v", name, macros)
+		}
+	}
+}
+
+func TestCompleteSubpackages(t *testing.T) {
+	text := testV3 + "  - docs:\n    - /usr/share/doc\n  - \n"
+	names := labels(t, text, 23, 4)
+	for _, name := range []string{"devel", "docs", "32bit"} {
+		if !names[name] {
+			t.Errorf("expected '%s', found: %v", name, names)
+		}
+	}
+}
+
+func TestHover(t *testing.T) {
+	msgs := session(t, open(testV3), at("textDocument/hover", 11, 3), at("textDocument/hover", 1, 8))
+	var h Hover
+	if err := json.Unmarshal(msgs[1].Result, &h); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if !strings.Contains(h.Contents.Value, "clang") || h.Range == nil || h.Range.Start.Character != 2 {
+		t.Errorf("expected documentation for '%s', found: %v", "flags.clang", h)
+	}
+	if result := string(msgs[2].Result); result != "null" {
+		t.Errorf("expected '%s', found: %s", "null", result)
+	}
+}
+
+func TestCompleteOutOfRange(t *testing.T) {
+	for _, character := range []int{-1, 1000} {
+		labels(t, testV3, 15, character)
+	}
+	macros := labels(t, strings.Replace(testV3, "  %configure\n", "  echo '\U0001D11E' %con\n", 1), 15, 16)
+	if !macros["%configure"] {
+		t.Errorf("expected '%s', found: %v", "%configure", macros)
+	}
+}
+
+func TestOffset(t *testing.T) {
+	line := "a\U0001D11Eb\u00e9c"
+	for character, expected := range map[int]int{-1: 0, 0: 0, 1: 1, 3: 5, 4: 6, 5: 8, 100: 9} {
+		if found := offset(line, character); found != expected {
+			t.Errorf("expected '%d', found: %d", expected, found)
+		}
+	}
+	if found := character(line, 6); found != 4 {
+		t.Errorf("expected '%d', found: %d", 4, found)
+	}
+}
+
+func TestUnknownMethod(t *testing.T) {
+	msgs := session(t, `{"jsonrpc":"2.0","id":1,"method":"workspace/symbol","params":{}}`, `{"jsonrpc":"2.0","method":"$/cancelRequest"}`)
+	if msgs[0].Error == nil || msgs[0].Error.Code != codeMethodNotFound {
+		t.Errorf("expected error %d, found: %v", codeMethodNotFound, msgs[0].Error)
+	}
+	if len(msgs) != 2 {
+		t.Errorf("expected only the shutdown response, found: %d messages", len(msgs))
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	req := `{"jsonrpc":"2.0","method":"exit"}`
+	in := bytes.NewBufferString(fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(req), req))
+	if err := NewServer(in, &bytes.Buffer{}).Serve(); err != ErrExitWithoutShutdown {
+		t.Errorf("expected '%s', found: %v", ErrExitWithoutShutdown, err)
+	}
+}
@@ -0,0 +1,71 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrNoContentLength indicates a message without the required Content-Length header
+var ErrNoContentLength = errors.New("message is missing the Content-Length header")
+
+// readMessage reads the body of a single message, framed by its headers
+func readMessage(in *bufio.Reader) (body []byte, err error) {
+	length := -1
+	for {
+		var line string
+		if line, err = in.ReadString('\n'); err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) == 0 {
+			break
+		}
+		pieces := strings.SplitN(line, ":", 2)
+		if len(pieces) == 2 && strings.EqualFold(strings.TrimSpace(pieces[0]), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(pieces[1])); err != nil {
+				return
+			}
+		}
+	}
+	if length < 0 {
+		err = ErrNoContentLength
+		return
+	}
+	body = make([]byte, length)
+	_, err = io.ReadFull(in, body)
+	return
+}
+
+// writeMessage writes a single message, framed by its headers
+func writeMessage(out io.Writer, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(out, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = out.Write(body)
+	return err
+}
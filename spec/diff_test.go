@@ -0,0 +1,137 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/internal"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const diffV3 = `YPKG: 3
+name: golang
+version: 1.16.3
+release: 143
+source:
+    - https://dl.google.com/go/go1.16.3.src.tar.gz : b298d29de9236ca47a023e382313bcc2d2eed31dfa706b60a04103ce83a71a25
+homepage: https://golang.org/
+license: BSD-3-Clause
+component: programming.tools
+summary: Go Programming Language
+description: |
+    Go is an open source programming language that makes it easy to build simple, reliable, and efficient software.
+deps:
+    build:
+        - golang
+        - git
+    run:
+        - devel:
+            - golang
+flags:
+    clang: no
+setup: |
+    %patch -p1 < $pkgfiles/0001-Disable-testing-for-Solus-builds.patch
+build: |
+    cd src
+    export GOROOT_FINAL="%libdir%/golang"
+    ./make.bash
+install: |
+    install -dm00755 $installdir/%libdir%/golang
+    cp -a * $installdir/%libdir%/golang/
+patterns:
+    - devel:
+        - /usr/lib64/golang/src
+        - /usr/lib64/golang/pkg
+`
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	old := writeHeaderTest(t, dir, "old.yml", headerV2)
+	new := writeHeaderTest(t, dir, "new.yml", diffV3)
+	changes, err := Diff(old, new)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	expected := []internal.Change{
+		{Field: "version", Action: internal.Changed, Old: "1.16.2", New: "1.16.3"},
+		{Field: "release", Action: internal.Changed, Old: "142", New: "143"},
+		{Field: "source", Action: internal.Removed, Old: "https://dl.google.com/go/go1.16.2.src.tar.gz"},
+		{Field: "source", Action: internal.Added, New: "https://dl.google.com/go/go1.16.3.src.tar.gz"},
+		{Field: "deps.build", Action: internal.Added, New: "git"},
+		{Field: "flags.clang", Action: internal.Changed, Old: "yes", New: "no"},
+		{Field: "build", Action: internal.Changed},
+		{Field: "patterns", Package: "devel", Action: internal.Added, New: "/usr/lib64/golang/pkg"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, found:\n%s", len(expected), changes)
+	}
+	for i, c := range expected {
+		found := changes[i]
+		found.Patch = ""
+		if found != c {
+			t.Errorf("expected '%s', found: %s", c, found)
+		}
+	}
+	if patch := changes[6].Patch; !strings.Contains(patch, "-./make.bash -v\n+./make.bash\n") {
+		t.Errorf("expected a patch of the build stage, found:\n%s", patch)
+	}
+	var decoded []map[string]string
+	out, err := changes.JSON()
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if err = json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if decoded[7]["package"] != "devel" || decoded[7]["action"] != "added" {
+		t.Errorf("expected a subpackage change, found: %v", decoded[7])
+	}
+}
+
+func TestDiffReordered(t *testing.T) {
+	dir := t.TempDir()
+	reordered := strings.Replace(diffV3, "        - golang\n        - git\n", "        - git\n        - golang\n", 1)
+	changes, err := Diff(writeHeaderTest(t, dir, "old.yml", diffV3), writeHeaderTest(t, dir, "new.yml", reordered))
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes, found:\n%s", changes)
+	}
+}
+
+func TestDiffMainPackageFirst(t *testing.T) {
+	dir := t.TempDir()
+	new := strings.Replace(headerV2, "patterns   :\n", "patterns   :\n    - 32bit:\n        - /usr/lib32/golang\n    - /usr/bin/go\n", 1)
+	changes, err := Diff(writeHeaderTest(t, dir, "old.yml", headerV2), writeHeaderTest(t, dir, "new.yml", new))
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	expected := []internal.Change{
+		{Field: "patterns", Action: internal.Added, New: "/usr/bin/go"},
+		{Field: "patterns", Package: "32bit", Action: internal.Added, New: "/usr/lib32/golang"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes, found: %v", len(expected), changes)
+	}
+	for i, c := range expected {
+		if changes[i] != c {
+			t.Errorf("expected '%v', found: %v", c, changes[i])
+		}
+	}
+}
@@ -0,0 +1,203 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/internal"
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"dev.getsol.us/source/libypkg.git/spec/shared/array"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownField indicates a key which is not part of a version of the format
+var ErrUnknownField = errors.New("unknown field")
+
+// Field documents a single key of one version of the package.yml format
+type Field struct {
+	// Path is the dotted path of the key, like "flags.emul32"
+	Path string
+	// Type describes the YAML accepted for the key
+	Type string
+	// Description explains what the key is for
+	Description string
+	// Example is a typical value for the key, written as YAML
+	Example string
+	// Default is the value used when the key is missing, if it has one
+	Default string
+	// Values are the only values allowed for the key, or every item in a list
+	Values []string
+}
+
+// Name gets the last key in the Path of this Field
+func (f Field) Name() string {
+	return f.Path[strings.LastIndex(f.Path, ".")+1:]
+}
+
+// String formats this Field for reading in a terminal
+func (f Field) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n\n", f.Path, f.Type)
+	if len(f.Description) > 0 {
+		fmt.Fprintf(&b, "    %s\n\n", f.Description)
+	}
+	if len(f.Default) > 0 {
+		fmt.Fprintf(&b, "    Default: %s\n", f.Default)
+	}
+	if len(f.Values) > 0 {
+		fmt.Fprintf(&b, "    Values:  %s\n", strings.Join(f.Values, ", "))
+	}
+	if len(f.Example) > 0 {
+		fmt.Fprintf(&b, "    Example: %s: %s\n", f.Name(), f.Example)
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// v2Docs maps the keys of the v2 format to the fields of internal.PackageYML, where they differ
+var v2Docs = map[string]string{
+	"builddeps": "deps.build",
+	"rundeps":   "deps.run",
+	"replaces":  "deps.replaces",
+	"conflicts": "deps.conflicts",
+}
+
+// docPath finds the path of a key in internal.Docs, for any version of the format
+func docPath(ypkg int, path string) string {
+	if ypkg != 2 {
+		return path
+	}
+	if doc, ok := v2Docs[path]; ok {
+		return doc
+	}
+	if _, ok := internal.Docs["flags."+path]; ok {
+		return "flags." + path
+	}
+	return path
+}
+
+// v2Flag finds the top-level v2 key for a flag written like the v3 format, such as "flags.emul32"
+func v2Flag(path string) (string, bool) {
+	if _, ok := internal.Docs[path]; ok && strings.HasPrefix(path, "flags.") {
+		return strings.TrimPrefix(path, "flags."), true
+	}
+	return "", false
+}
+
+var (
+	// Types with custom YAML marshaling, which are described specially
+	defaultTrueType  = reflect.TypeOf(shared.DefaultTrue{})
+	defaultFalseType = reflect.TypeOf(shared.DefaultFalse{})
+	licensesType     = reflect.TypeOf(shared.Licenses{})
+	sourceType       = reflect.TypeOf(shared.SourceURI{})
+	mapType          = reflect.TypeOf(array.Map{})
+)
+
+// typeName describes the YAML accepted for a Go type
+func typeName(t reflect.Type) string {
+	switch t {
+	case defaultTrueType, defaultFalseType:
+		return "yes/no"
+	case licensesType:
+		return "string or list of strings"
+	case sourceType:
+		return "'URI : hash' entry"
+	case mapType:
+		return "string, or list of a string followed by 'subpackage: string' entries"
+	case listMapType:
+		return "list of strings, optionally grouped as 'subpackage: [strings]'"
+	case nodeType:
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Uint:
+		return "integer"
+	case reflect.Struct:
+		return "mapping"
+	case reflect.Slice:
+		return "list of " + typeName(t.Elem()) + "s"
+	}
+	return t.Kind().String()
+}
+
+// registries document every key of each supported format, by path
+var registries = map[int]map[string]Field{
+	2: newRegistry(2),
+	3: newRegistry(3),
+}
+
+// newRegistry documents every key in the layout of a format
+func newRegistry(ypkg int) map[string]Field {
+	l := layouts[ypkg]
+	r := make(map[string]Field)
+	for path, t := range l.types {
+		doc := internal.Docs[docPath(ypkg, path)]
+		f := Field{
+			Path:        path,
+			Type:        typeName(t),
+			Description: doc.Description,
+			Example:     doc.Example,
+			Default:     doc.Default,
+			Values:      doc.Values,
+		}
+		switch {
+		case l.stages[path], path == "environment":
+			f.Type = "shell script"
+		case path == "YPKG":
+			f.Values = []string{strconv.Itoa(ypkg)}
+		}
+		r[path] = f
+	}
+	return r
+}
+
+// Explain gets the documentation for a key in a supported version of the format, like "flags.emul32"
+//
+// For YPKG 2, where the flags are top-level keys, their v3 paths like "flags.emul32" are also accepted.
+func Explain(ypkg int, path string) (f Field, err error) {
+	r, ok := registries[ypkg]
+	if !ok {
+		err = fmt.Errorf("%w: %d", ErrInvalidVersion, ypkg)
+		return
+	}
+	f, ok = r[path]
+	if key, alias := v2Flag(path); !ok && ypkg == 2 && alias {
+		f, ok = r[key]
+	}
+	if !ok {
+		err = fmt.Errorf("%w: '%s' in YPKG %d", ErrUnknownField, path, ypkg)
+	}
+	return
+}
+
+// Fields gets the documentation for every key in a supported version of the format, in canonical order
+func Fields(ypkg int) (fs []Field) {
+	var walk func(path string)
+	walk = func(path string) {
+		for _, name := range Keys(ypkg, path) {
+			child := join(path, name)
+			fs = append(fs, registries[ypkg][child])
+			walk(child)
+		}
+	}
+	walk("")
+	return
+}
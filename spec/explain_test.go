@@ -0,0 +1,105 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"errors"
+	"gopkg.in/yaml.v3"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	cases := []struct {
+		ypkg            int
+		path, kind, def string
+	}{
+		{3, "flags.emul32", "yes/no", "no"},
+		{3, "flags.clang", "yes/no", "yes"},
+		{2, "avx2", "yes/no", "no"},
+		{2, "flags.emul32", "yes/no", "no"},
+		{2, "flags.clang", "yes/no", "yes"},
+		{3, "install", "shell script", ""},
+		{2, "component", "string, or list of a string followed by 'subpackage: string' entries", ""},
+		{3, "component", "string", ""},
+	}
+	for _, c := range cases {
+		f, err := Explain(c.ypkg, c.path)
+		if err != nil {
+			t.Fatalf("Expected no error, found: %s", err)
+		}
+		if f.Type != c.kind {
+			t.Errorf("expected '%s', found: %s", c.kind, f.Type)
+		}
+		if f.Default != c.def {
+			t.Errorf("expected '%s', found: %s", c.def, f.Default)
+		}
+	}
+}
+
+func TestExplainVersions(t *testing.T) {
+	v2, err := Explain(2, "builddeps")
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	v3, err := Explain(3, "deps.build")
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if v2.Description != v3.Description {
+		t.Errorf("expected '%s', found: %s", v3.Description, v2.Description)
+	}
+	if _, err = Explain(2, "deps.build"); !errors.Is(err, ErrUnknownField) {
+		t.Errorf("expected '%s', found: %v", ErrUnknownField, err)
+	}
+	if _, err = Explain(4, "name"); !errors.Is(err, ErrInvalidVersion) {
+		t.Errorf("expected '%s', found: %v", ErrInvalidVersion, err)
+	}
+}
+
+func TestFieldsDocumented(t *testing.T) {
+	for _, ypkg := range []int{2, 3} {
+		for _, f := range Fields(ypkg) {
+			if len(f.Description) == 0 {
+				t.Errorf("expected a description for '%s' in YPKG %d", f.Path, ypkg)
+			}
+			var example interface{}
+			if err := yaml.Unmarshal([]byte(f.Example), &example); err != nil || example == nil {
+				t.Errorf("expected a valid example for '%s', found: %s", f.Path, f.Example)
+			}
+		}
+	}
+	if keys := Keys(3, "flags.clang"); len(keys) != 0 {
+		t.Errorf("expected no keys in '%s', found: %v", "flags.clang", keys)
+	}
+}
+
+func TestFieldString(t *testing.T) {
+	f, err := Explain(3, "flags.emul32")
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	expected := `flags.emul32: yes/no
+
+    Build an extra set of 32-bit libraries into a 32bit subpackage.
+
+    Default: no
+    Example: emul32: yes
+`
+	if result := f.String(); result != expected {
+		t.Errorf("expected '%s', found: %s", expected, result)
+	}
+}
@@ -0,0 +1,330 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"bytes"
+	"dev.getsol.us/source/libypkg.git/spec/shared/array"
+	"dev.getsol.us/source/libypkg.git/spec/shared/diff"
+	"dev.getsol.us/source/libypkg.git/spec/v2"
+	"dev.getsol.us/source/libypkg.git/spec/v3"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrNotFormatted indicates that a package.yml is not in the canonical format
+	ErrNotFormatted = errors.New("package.yml is not formatted")
+	// ErrNotPackage indicates a YAML document which is not a mapping at the top-level
+	ErrNotPackage = errors.New("package.yml must be a YAML mapping")
+)
+
+// layout is the canonical structure of one version of the package.yml format
+type layout struct {
+	// order is the order of keys for every mapping, by path
+	order map[string][]string
+	// lists are the paths of every ListMap
+	lists map[string]bool
+	// stages are the paths of every build stage script
+	stages map[string]bool
+	// types are the Go types of every key, by path
+	types map[string]reflect.Type
+}
+
+// layouts are the canonical structures of each supported format, taken from the yaml tags of each PackageYML
+var layouts = map[int]*layout{
+	2: newLayout(reflect.TypeOf(v2.PackageYML{}), reflect.TypeOf(v2.BuildStages{})),
+	3: newLayout(reflect.TypeOf(v3.PackageYML{}), reflect.TypeOf(v3.BuildStages{})),
+}
+
+var (
+	// listMapType is used to find the ListMap fields while building a layout
+	listMapType = reflect.TypeOf(array.ListMap{})
+	// marshalerType is used to find structs which are written as scalars, like DefaultTrue
+	marshalerType = reflect.TypeOf((*yaml.Marshaler)(nil)).Elem()
+)
+
+// newLayout builds the layout of a PackageYML type
+func newLayout(pkg, stages reflect.Type) *layout {
+	l := &layout{
+		order:  make(map[string][]string),
+		lists:  make(map[string]bool),
+		stages: make(map[string]bool),
+		types:  make(map[string]reflect.Type),
+	}
+	l.walk(pkg, "", stages)
+	return l
+}
+
+// walk adds the fields of a struct to the layout, in the order they are declared
+func (l *layout) walk(t reflect.Type, path string, stages reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		inline := false
+		for _, opt := range tag[1:] {
+			inline = inline || opt == "inline"
+		}
+		if inline {
+			l.walk(field.Type, path, stages)
+			continue
+		}
+		if len(name) == 0 {
+			name = strings.ToLower(field.Name)
+		}
+		l.order[path] = append(l.order[path], name)
+		child := join(path, name)
+		l.types[child] = field.Type
+		switch {
+		case t == stages:
+			l.stages[child] = true
+		case field.Type == listMapType:
+			l.lists[child] = true
+		case field.Type.Kind() == reflect.Struct && !field.Type.Implements(marshalerType):
+			l.walk(field.Type, child, stages)
+		}
+	}
+}
+
+// Keys gets the keys allowed in a mapping of a supported version of the format, in canonical order
+//
+// The path is empty for the top-level keys, or the dotted path of a nested mapping like "flags".
+func Keys(ypkg int, path string) []string {
+	l, ok := layouts[ypkg]
+	if !ok {
+		return nil
+	}
+	return append([]string(nil), l.order[path]...)
+}
+
+// IsStage checks if a key holds a build stage script in a supported version of the format
+func IsStage(ypkg int, key string) bool {
+	l, ok := layouts[ypkg]
+	return ok && l.stages[key]
+}
+
+// join adds a key to the path of a mapping
+func join(path, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+// format rewrites a mapping in place to match the layout
+func (l *layout) format(node *yaml.Node, path string) {
+	order := l.order[path]
+	rank := func(key string) int {
+		for i, k := range order {
+			if k == key {
+				return i
+			}
+		}
+		// Unknown keys are kept after the known ones
+		return len(order)
+	}
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+	}
+	var header string
+	if len(pairs) > 0 && len(path) == 0 {
+		// A comment at the very top of the file stays there
+		header, pairs[0][0].HeadComment = pairs[0][0].HeadComment, ""
+	}
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return rank(pairs[i][0].Value) < rank(pairs[j][0].Value)
+	})
+	if len(header) > 0 {
+		pairs[0][0].HeadComment = strings.TrimSpace(header + "\n" + pairs[0][0].HeadComment)
+	}
+	node.Content = node.Content[:0]
+	for _, pair := range pairs {
+		key, value := pair[0], pair[1]
+		key.Style = 0
+		child := join(path, key.Value)
+		switch {
+		case l.stages[child] && value.Kind == yaml.ScalarNode:
+			value.Style = yaml.LiteralStyle
+			value.Value = strings.TrimRight(value.Value, "\n") + "\n"
+		case l.lists[child] && value.Kind == yaml.SequenceNode:
+			sortListMap(value)
+			plain(value)
+		case value.Kind == yaml.MappingNode && len(l.order[child]) > 0:
+			l.format(value, child)
+		default:
+			plain(value)
+		}
+		node.Content = append(node.Content, key, value)
+	}
+}
+
+// yaml11Scalar matches the plain scalars which ypkg reads as a bool, null or number, following the
+// YAML 1.1 resolver of PyYAML rather than the YAML 1.2 one of yaml.v3
+var yaml11Scalar = regexp.MustCompile(`^(?:` +
+	// bool
+	`y|Y|yes|Yes|YES|n|N|no|No|NO|true|True|TRUE|false|False|FALSE|on|On|ON|off|Off|OFF` +
+	// null
+	`|~|null|Null|NULL|` +
+	// int
+	`|[-+]?0b[0-1_]+|[-+]?0[0-7_]+|[-+]?(?:0|[1-9][0-9_]*)|[-+]?0x[0-9a-fA-F_]+|[-+]?[1-9][0-9_]*(?::[0-5]?[0-9])+` +
+	// float
+	`|[-+]?(?:[0-9][0-9_]*)\.[0-9_]*(?:[eE][-+][0-9]+)?|\.[0-9_]+(?:[eE][-+][0-9]+)?` +
+	`|[-+]?[0-9][0-9_]*(?::[0-5]?[0-9])+\.[0-9_]*|[-+]?\.(?:inf|Inf|INF)|\.(?:nan|NaN|NAN)` +
+	`)$`)
+
+// plain resets the styling of a node and its children, so the encoder picks the simplest one
+//
+// Quoting is still kept wherever it changes the meaning of a value, including strings which ypkg
+// would otherwise read as a bool, null or number.
+func plain(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		quoted := node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) != 0
+		if strings.Contains(node.Value, "\n") {
+			node.Style = yaml.LiteralStyle
+		} else if !quoted || node.Tag == "!!str" && !yaml11Scalar.MatchString(node.Value) {
+			node.Style = 0
+		}
+	case yaml.MappingNode, yaml.SequenceNode:
+		node.Style &^= yaml.FlowStyle
+		for _, child := range node.Content {
+			plain(child)
+		}
+	}
+}
+
+// sortListMap puts the entries for the main package first, followed by each subpackage by name
+//
+// Repeated lists for the same subpackage are merged into one, like array.ListMap does. Any
+// other repeated entry is kept as it is, right after the first, so that nothing is lost.
+func sortListMap(seq *yaml.Node) {
+	var main []*yaml.Node
+	subs := make(map[string][]*yaml.Node)
+	var names []string
+	for _, entry := range seq.Content {
+		if entry.Kind != yaml.MappingNode || len(entry.Content) != 2 {
+			main = append(main, entry)
+			continue
+		}
+		name := entry.Content[0].Value
+		prev, ok := subs[name]
+		if !ok {
+			names = append(names, name)
+		} else if first := prev[0].Content[1]; first.Kind == yaml.SequenceNode && entry.Content[1].Kind == yaml.SequenceNode {
+			first.Content = append(first.Content, entry.Content[1].Content...)
+			continue
+		}
+		subs[name] = append(prev, entry)
+	}
+	sort.Strings(names)
+	seq.Content = main
+	for _, name := range names {
+		seq.Content = append(seq.Content, subs[name]...)
+	}
+}
+
+// Format rewrites the contents of a package.yml in the canonical layout for its version
+//
+// Keys are ordered like the fields of the matching PackageYML, stages are written as
+// literal blocks, subpackage entries are sorted, and indentation is 4 spaces. Comments
+// are kept with the keys and values they belong to.
+func Format(in []byte) (out []byte, err error) {
+	var doc yaml.Node
+	if err = yaml.Unmarshal(in, &doc); err != nil {
+		return
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		err = ErrNotPackage
+		return
+	}
+	root := doc.Content[0]
+	ypkg := 2
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "YPKG" {
+			if ypkg, err = strconv.Atoi(root.Content[i+1].Value); err != nil {
+				err = ErrInvalidVersion
+				return
+			}
+		}
+	}
+	l, ok := layouts[ypkg]
+	if !ok {
+		err = ErrInvalidVersion
+		return
+	}
+	root.Style = 0
+	l.format(root, "")
+	var buff bytes.Buffer
+	enc := yaml.NewEncoder(&buff)
+	enc.SetIndent(4)
+	if err = enc.Encode(&doc); err != nil {
+		return
+	}
+	if err = enc.Close(); err != nil {
+		return
+	}
+	out = buff.Bytes()
+	return
+}
+
+// FormatFile rewrites a package.yml in the canonical layout, only writing it if anything changed
+func FormatFile(path string) (changed bool, err error) {
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	out, err := Format(in)
+	if err != nil || bytes.Equal(in, out) {
+		return
+	}
+	changed = true
+	err = ioutil.WriteFile(path, out, 0644)
+	return
+}
+
+// CheckFormat checks that a package.yml is already in the canonical layout, without changing it
+//
+// If it is not, a unified diff of the changes Format would make is returned with ErrNotFormatted.
+func CheckFormat(path string) (patch string, err error) {
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		return
+	}
+	out, err := Format(in)
+	if err != nil {
+		return
+	}
+	if patch = diff.Unified(path, path+" (formatted)", string(in), string(out), 3); len(patch) > 0 {
+		err = fmt.Errorf("%w: %s", ErrNotFormatted, path)
+	}
+	return
+}
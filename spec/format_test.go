@@ -0,0 +1,156 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const unformatted = `# Maintained by the Go team
+summary: 'Go Programming Language'
+name       : golang
+version: "1.16.2"
+release    : 142
+install    : |
+    install -dm00755 $installdir
+build: cd src && ./make.bash
+patterns   :
+    - devel:
+        - /usr/lib64/golang/src
+    - /usr/share/golang
+    - docs: [/usr/share/doc/golang]
+    - devel:
+        - /usr/include/golang # headers
+source     :
+    - https://dl.google.com/go/go1.16.2.src.tar.gz : 37ca14287a23cb8ba2ac3f5c3dd8adbc1f7a54b9701a57824bf19a0b271f83ea
+clang: no
+builddeps  :
+    # needed to bootstrap
+    - golang
+`
+
+const formatted = `# Maintained by the Go team
+name: golang
+version: 1.16.2
+release: 142
+source:
+    - https://dl.google.com/go/go1.16.2.src.tar.gz: 37ca14287a23cb8ba2ac3f5c3dd8adbc1f7a54b9701a57824bf19a0b271f83ea
+summary: Go Programming Language
+builddeps:
+    # needed to bootstrap
+    - golang
+clang: no
+build: |
+    cd src && ./make.bash
+install: |
+    install -dm00755 $installdir
+patterns:
+    - /usr/share/golang
+    - devel:
+        - /usr/lib64/golang/src
+        - /usr/include/golang # headers
+    - docs:
+        - /usr/share/doc/golang
+`
+
+func TestFormat(t *testing.T) {
+	out, err := Format([]byte(unformatted))
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if string(out) != formatted {
+		t.Fatalf("expected:\n%s\nfound:\n%s", formatted, out)
+	}
+	again, err := Format(out)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if string(again) != string(out) {
+		t.Fatalf("expected formatting to be stable, found:\n%s", again)
+	}
+}
+
+func TestFormatV3(t *testing.T) {
+	in := "YPKG: 3\nflags:\n    debug: no\n    clang: no\nname: golang\ndeps:\n    run:\n        - b:\n            - golang\n        - a:\n            - golang\n    build:\n        - golang\n"
+	expected := "YPKG: 3\nname: golang\ndeps:\n    build:\n        - golang\n    run:\n        - a:\n            - golang\n        - b:\n            - golang\nflags:\n    clang: no\n    debug: no\n"
+	out, err := Format([]byte(in))
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if string(out) != expected {
+		t.Fatalf("expected:\n%s\nfound:\n%s", expected, out)
+	}
+}
+
+func TestFormatDuplicateScalar(t *testing.T) {
+	in := "name: golang\npatterns:\n    - devel: /usr/include/golang\n    - docs: /usr/share/doc\n    - devel:\n        - /usr/lib64/golang/src\n    - devel: /usr/lib64/pkgconfig\n"
+	expected := "name: golang\npatterns:\n    - devel: /usr/include/golang\n    - devel:\n        - /usr/lib64/golang/src\n    - devel: /usr/lib64/pkgconfig\n    - docs: /usr/share/doc\n"
+	out, err := Format([]byte(in))
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if string(out) != expected {
+		t.Fatalf("expected:\n%s\nfound:\n%s", expected, out)
+	}
+}
+
+func TestFormatQuotedScalars(t *testing.T) {
+	in := "name: golang\nversion: \"1.10\"\nrelease: 1\nsummary: \"yes\"\ndescription: 'Go'\n"
+	expected := "name: golang\nversion: \"1.10\"\nrelease: 1\nsummary: \"yes\"\ndescription: Go\n"
+	out, err := Format([]byte(in))
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if string(out) != expected {
+		t.Fatalf("expected:\n%s\nfound:\n%s", expected, out)
+	}
+}
+
+func TestFormatInvalid(t *testing.T) {
+	if _, err := Format([]byte("- not\n- a\n- package\n")); err != ErrNotPackage {
+		t.Errorf("expected ErrNotPackage, found: %v", err)
+	}
+	if _, err := Format([]byte("YPKG: 9\nname: golang\n")); err != ErrInvalidVersion {
+		t.Errorf("expected ErrInvalidVersion, found: %v", err)
+	}
+}
+
+func TestCheckFormat(t *testing.T) {
+	dir := t.TempDir()
+	path := writeHeaderTest(t, dir, "package.yml", unformatted)
+	patch, err := CheckFormat(path)
+	if !errors.Is(err, ErrNotFormatted) {
+		t.Fatalf("expected ErrNotFormatted, found: %v", err)
+	}
+	if !strings.Contains(patch, "-name       : golang\n") || !strings.Contains(patch, "+name: golang\n") {
+		t.Errorf("expected a diff of the changes, found:\n%s", patch)
+	}
+	changed, err := FormatFile(path)
+	if err != nil || !changed {
+		t.Fatalf("expected a change without error, found: %t, %v", changed, err)
+	}
+	content, _ := ioutil.ReadFile(path)
+	if string(content) != formatted {
+		t.Fatalf("expected:\n%s\nfound:\n%s", formatted, content)
+	}
+	if patch, err = CheckFormat(path); err != nil || len(patch) > 0 {
+		t.Fatalf("expected no changes, found: %v\n%s", err, patch)
+	}
+}
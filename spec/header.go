@@ -0,0 +1,115 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"bufio"
+	"bytes"
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"strings"
+)
+
+// Header is the subset of a package.yml which identifies a package and its sources
+type Header struct {
+	YPKG     int                `yaml:"YPKG"`
+	Name     string             `yaml:"name"`
+	Version  string             `yaml:"version"`
+	Release  uint               `yaml:"release"`
+	Source   []shared.SourceURI `yaml:"source"`
+	Homepage string             `yaml:"homepage"`
+}
+
+// headerKeys are the top-level keys that make up a Header
+var headerKeys = map[string]bool{
+	"YPKG":     true,
+	"name":     true,
+	"version":  true,
+	"release":  true,
+	"source":   true,
+	"homepage": true,
+}
+
+// LoadHeader reads only the Header fields of any supported package.yml
+func LoadHeader(path string) (h Header, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return ReadHeader(f)
+}
+
+// ReadHeader reads only the Header fields of a package.yml from any Reader
+//
+// Rather than decoding the whole file, the top-level blocks for each of the Header
+// keys are picked out line by line and only those are decoded. Reading stops early
+// once every Header key has been seen, wherever they are in the file.
+func ReadHeader(in io.Reader) (h Header, err error) {
+	var buff bytes.Buffer
+	seen := make(map[string]bool)
+	var keep bool
+	r := bufio.NewReader(in)
+	for {
+		line, rerr := r.ReadString('\n')
+		if key, ok := topLevelKey(line); ok {
+			if len(seen) == len(headerKeys) {
+				break
+			}
+			if keep = headerKeys[key]; keep {
+				seen[key] = true
+			}
+		}
+		if keep {
+			buff.WriteString(line)
+		}
+		if rerr == io.EOF {
+			break
+		}
+		if rerr != nil {
+			err = rerr
+			return
+		}
+	}
+	if err = yaml.Unmarshal(buff.Bytes(), &h); err != nil {
+		return
+	}
+	if h.YPKG == 0 {
+		h.YPKG = 2
+	}
+	return
+}
+
+// topLevelKey gets the name of the key if this line starts a new top-level mapping entry
+func topLevelKey(line string) (key string, ok bool) {
+	if len(line) == 0 {
+		return
+	}
+	switch line[0] {
+	case ' ', '\t', '\r', '\n', '#', '-', '.':
+		return
+	}
+	end := strings.Index(line, ":")
+	if end < 1 {
+		return
+	}
+	key = strings.Trim(strings.TrimSpace(line[:end]), `"'`)
+	ok = len(key) > 0
+	return
+}
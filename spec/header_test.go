@@ -0,0 +1,209 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const headerV2 = `name       : golang
+version    : 1.16.2
+release    : 142
+source     :
+    - https://dl.google.com/go/go1.16.2.src.tar.gz : 37ca14287a23cb8ba2ac3f5c3dd8adbc1f7a54b9701a57824bf19a0b271f83ea
+homepage   : https://golang.org/
+license    : BSD-3-Clause
+component  : programming.tools
+summary    : Go Programming Language
+description: |
+    Go is an open source programming language that makes it easy to build simple, reliable, and efficient software.
+builddeps  :
+    - golang
+rundeps    :
+    - devel:
+        - golang
+setup      : |
+    %patch -p1 < $pkgfiles/0001-Disable-testing-for-Solus-builds.patch
+build      : |
+    cd src
+    export GOROOT_FINAL="%libdir%/golang"
+    ./make.bash -v
+install    : |
+    install -dm00755 $installdir/%libdir%/golang
+    cp -a * $installdir/%libdir%/golang/
+patterns   :
+    - devel:
+        - /usr/lib64/golang/src
+`
+
+const headerV3 = `YPKG: 3
+name: golang
+version: 1.16.2
+release: 142
+source:
+    - https://dl.google.com/go/go1.16.2.src.tar.gz : 37ca14287a23cb8ba2ac3f5c3dd8adbc1f7a54b9701a57824bf19a0b271f83ea
+homepage: https://golang.org/
+license: BSD-3-Clause
+component: programming.tools
+summary: Go Programming Language
+description: |
+    Go is an open source programming language that makes it easy to build simple, reliable, and efficient software.
+deps:
+    build:
+        - golang
+    run:
+        - devel:
+            - golang
+install: |
+    install -dm00755 $installdir/%libdir%/golang
+    cp -a * $installdir/%libdir%/golang/
+`
+
+func writeHeaderTest(t testing.TB, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	return path
+}
+
+func checkHeader(t *testing.T, h Header, ypkg int) {
+	if h.YPKG != ypkg {
+		t.Errorf("expected '%d', found: %d", ypkg, h.YPKG)
+	}
+	if h.Name != "golang" {
+		t.Errorf("expected '%s', found: %s", "golang", h.Name)
+	}
+	if h.Version != "1.16.2" {
+		t.Errorf("expected '%s', found: %s", "1.16.2", h.Version)
+	}
+	if h.Release != 142 {
+		t.Errorf("expected '%d', found: %d", 142, h.Release)
+	}
+	if h.Homepage != "https://golang.org/" {
+		t.Errorf("expected '%s', found: %s", "https://golang.org/", h.Homepage)
+	}
+	if len(h.Source) != 1 {
+		t.Fatalf("expected 1 source, found: %d", len(h.Source))
+	}
+	url := "https://dl.google.com/go/go1.16.2.src.tar.gz"
+	sum := "37ca14287a23cb8ba2ac3f5c3dd8adbc1f7a54b9701a57824bf19a0b271f83ea"
+	src := h.Source[0]
+	if src.URL != url {
+		t.Errorf("expected '%s', found: %s", url, src.URL)
+	}
+	if src.Digest.Value != sum {
+		t.Errorf("expected '%s', found: %s", sum, src.Digest.Value)
+	}
+}
+
+func TestLoadHeaderV2(t *testing.T) {
+	path := writeHeaderTest(t, t.TempDir(), "package.yml", headerV2)
+	h, err := LoadHeader(path)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	checkHeader(t, h, 2)
+}
+
+func TestLoadHeaderV3(t *testing.T) {
+	path := writeHeaderTest(t, t.TempDir(), "package.yml", headerV3)
+	h, err := LoadHeader(path)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	checkHeader(t, h, 3)
+}
+
+// failReader fails every read, to find out if a reader goes past where it should stop
+type failReader struct{}
+
+func (failReader) Read([]byte) (int, error) {
+	return 0, errors.New("read past the header")
+}
+
+func TestReadHeaderStopsEarly(t *testing.T) {
+	end := strings.Index(headerV3, "component")
+	h, err := ReadHeader(io.MultiReader(strings.NewReader(headerV3[:end]), failReader{}))
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	checkHeader(t, h, 3)
+}
+
+func TestReadHeaderLateHomepage(t *testing.T) {
+	homepage := "homepage   : https://golang.org/\n"
+	content := strings.Replace(headerV2, homepage, "", 1)
+	content = strings.Replace(content, "rundeps    :", homepage+"rundeps    :", 1)
+	h, err := ReadHeader(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	checkHeader(t, h, 2)
+}
+
+func TestLoadHeaderMissing(t *testing.T) {
+	if _, err := LoadHeader(filepath.Join(t.TempDir(), "package.yml")); !os.IsNotExist(err) {
+		t.Fatalf("Expected not exist error, found: %v", err)
+	}
+}
+
+// benchmarkTree writes out several thousand package.yml files for benchmarking
+func benchmarkTree(b *testing.B) (paths []string) {
+	dir := b.TempDir()
+	for i := 0; i < 4000; i++ {
+		content := headerV2
+		if i%2 == 0 {
+			content = headerV3
+		}
+		paths = append(paths, writeHeaderTest(b, dir, fmt.Sprintf("package-%d.yml", i), content))
+	}
+	return
+}
+
+func BenchmarkLoad(b *testing.B) {
+	paths := benchmarkTree(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range paths {
+			pkg, err := Load(path)
+			if err != nil {
+				b.Fatalf("Expected no error, found: %s", err)
+			}
+			pkg.Close()
+		}
+	}
+}
+
+func BenchmarkLoadHeader(b *testing.B) {
+	paths := benchmarkTree(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, path := range paths {
+			if _, err := LoadHeader(path); err != nil {
+				b.Fatalf("Expected no error, found: %s", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"bufio"
+	"bytes"
+	"dev.getsol.us/source/libypkg.git/spec/internal"
+	"dev.getsol.us/source/libypkg.git/spec/shared/version"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrReleaseNotIncreased indicates a changed package.yml which kept the release of the last commit
+	ErrReleaseNotIncreased = errors.New("release must be greater than the last committed release")
+	// ErrVersionWithoutRelease indicates a new version without a new release
+	ErrVersionWithoutRelease = errors.New("version changed without increasing the release")
+	// ErrReleaseReused indicates a release which was already used by an earlier commit, like after a revert
+	ErrReleaseReused = errors.New("release has already been used by an earlier commit")
+)
+
+// Revision is the Header of a package.yml as it was at a single commit
+type Revision struct {
+	Commit string
+	Header
+}
+
+// History is every committed Revision of a package.yml, newest first
+type History []Revision
+
+// git runs a git command in a directory and returns its output
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	return cmd.Output()
+}
+
+// LoadHistory reads every committed Revision of a package.yml from its git repository
+//
+// Commits which delete the file, or where it could not be read, are skipped.
+func LoadHistory(path string) (hist History, err error) {
+	if path, err = filepath.Abs(path); err != nil {
+		return
+	}
+	dir, name := filepath.Split(path)
+	if _, err = git(dir, "rev-parse", "--git-dir"); err != nil {
+		err = fmt.Errorf("'%s' is not in a git repository: %w", path, err)
+		return
+	}
+	if _, herr := git(dir, "rev-parse", "--verify", "--quiet", "HEAD"); herr != nil {
+		// Nothing has been committed yet
+		return
+	}
+	out, err := git(dir, "log", "--format=%H", "--", name)
+	if err != nil {
+		err = fmt.Errorf("failed to read the git history of '%s': %w", path, err)
+		return
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		commit := strings.TrimSpace(scanner.Text())
+		content, gerr := git(dir, "show", commit+":./"+name)
+		if gerr != nil {
+			continue
+		}
+		h, herr := ReadHeader(bytes.NewReader(content))
+		if herr != nil {
+			continue
+		}
+		hist = append(hist, Revision{
+			Commit: commit,
+			Header: h,
+		})
+	}
+	return
+}
+
+// Check makes sure that the release of a changed package.yml has been increased correctly
+//
+// The release must be greater than the last committed one, and greater than any release
+// ever committed, so that releases are never reused after a revert. The version must not
+// go backwards.
+func (hist History) Check(h Header) error {
+	if len(hist) == 0 {
+		return nil
+	}
+	last := hist[0]
+	if version.Compare(h.Version, last.Version) < 0 {
+		return fmt.Errorf("%w: %s is older than %s from commit %.12s", internal.ErrDowngrade, h.Version, last.Version, last.Commit)
+	}
+	if h.Release <= last.Release {
+		if h.Version != last.Version {
+			return fmt.Errorf("%w: %d is not greater than %d from commit %.12s", ErrVersionWithoutRelease, h.Release, last.Release, last.Commit)
+		}
+		return fmt.Errorf("%w: %d is not greater than %d from commit %.12s", ErrReleaseNotIncreased, h.Release, last.Release, last.Commit)
+	}
+	for _, rev := range hist[1:] {
+		if h.Release <= rev.Release {
+			return fmt.Errorf("%w: %d is not greater than %d from commit %.12s", ErrReleaseReused, h.Release, rev.Release, rev.Commit)
+		}
+	}
+	return nil
+}
+
+// CheckRelease checks the release of a package.yml against its git history
+//
+// When "staged" is set, the copy in the git index is checked instead of the working
+// tree, as in a pre-commit hook. Nothing is checked for new or unchanged files.
+func CheckRelease(path string, staged bool) (err error) {
+	if path, err = filepath.Abs(path); err != nil {
+		return
+	}
+	hist, err := LoadHistory(path)
+	if err != nil {
+		return
+	}
+	dir, name := filepath.Split(path)
+	var current []byte
+	if staged {
+		current, err = git(dir, "show", ":./"+name)
+	} else {
+		current, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		return
+	}
+	if committed, gerr := git(dir, "show", "HEAD:./"+name); gerr == nil && bytes.Equal(current, committed) {
+		return
+	}
+	h, err := ReadHeader(bytes.NewReader(current))
+	if err != nil {
+		return
+	}
+	return hist.Check(h)
+}
@@ -0,0 +1,123 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/internal"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runGit runs a git command in a test repository, failing the test on error
+func runGit(t *testing.T, dir string, args ...string) {
+	args = append([]string{"-C", dir, "-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		t.Fatalf("git %s failed: %s\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+// release rewrites the version and release of the headerV2 fixture
+func release(ver string, rel string) string {
+	content := strings.Replace(headerV2, "release    : 142", "release    : "+rel, 1)
+	return strings.Replace(content, "version    : 1.16.2", "version    : "+ver, 1)
+}
+
+// newHistory creates a git repository with a package.yml committed once for each content
+func newHistory(t *testing.T, contents ...string) (path string) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+	path = filepath.Join(dir, "package.yml")
+	for _, content := range contents {
+		writeHeaderTest(t, dir, "package.yml", content)
+		runGit(t, dir, "add", "package.yml")
+		runGit(t, dir, "commit", "-q", "-m", "update")
+	}
+	return
+}
+
+func TestLoadHistory(t *testing.T) {
+	path := newHistory(t, release("1.16.1", "141"), release("1.16.2", "142"))
+	hist, err := LoadHistory(path)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if len(hist) != 2 {
+		t.Fatalf("expected 2 revisions, found: %d", len(hist))
+	}
+	if hist[0].Release != 142 || hist[1].Release != 141 {
+		t.Errorf("expected releases 142 and 141, found: %d and %d", hist[0].Release, hist[1].Release)
+	}
+	if len(hist[0].Commit) != 40 {
+		t.Errorf("expected a commit hash, found: %s", hist[0].Commit)
+	}
+}
+
+func TestCheckRelease(t *testing.T) {
+	cases := []struct {
+		name     string
+		current  string
+		expected error
+	}{
+		{"unchanged", release("1.16.2", "142"), nil},
+		{"bumped", release("1.16.2", "143"), nil},
+		{"updated", release("1.16.3", "143"), nil},
+		{"not increased", strings.Replace(release("1.16.2", "142"), "BSD-3-Clause", "MIT", 1), ErrReleaseNotIncreased},
+		{"version without release", release("1.16.3", "142"), ErrVersionWithoutRelease},
+		{"downgrade", release("1.16.1", "143"), internal.ErrDowngrade},
+	}
+	for _, c := range cases {
+		path := newHistory(t, release("1.16.1", "141"), release("1.16.2", "142"))
+		writeHeaderTest(t, filepath.Dir(path), "package.yml", c.current)
+		if err := CheckRelease(path, false); !errors.Is(err, c.expected) {
+			t.Errorf("%s: expected '%v', found: %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestCheckReleaseReverted(t *testing.T) {
+	path := newHistory(t, release("1.16.1", "141"), release("1.16.2", "142"), release("1.16.1", "141"))
+	writeHeaderTest(t, filepath.Dir(path), "package.yml", release("1.16.1", "142"))
+	if err := CheckRelease(path, false); !errors.Is(err, ErrReleaseReused) {
+		t.Fatalf("expected ErrReleaseReused, found: %v", err)
+	}
+}
+
+func TestCheckReleaseStaged(t *testing.T) {
+	path := newHistory(t, release("1.16.1", "141"))
+	dir := filepath.Dir(path)
+	writeHeaderTest(t, dir, "package.yml", release("1.16.2", "141"))
+	runGit(t, dir, "add", "package.yml")
+	// Only the staged copy is checked, not the working tree
+	writeHeaderTest(t, dir, "package.yml", release("1.16.2", "142"))
+	if err := CheckRelease(path, true); !errors.Is(err, ErrVersionWithoutRelease) {
+		t.Fatalf("expected ErrVersionWithoutRelease, found: %v", err)
+	}
+	if err := CheckRelease(path, false); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+}
+
+func TestCheckReleaseNew(t *testing.T) {
+	path := newHistory(t)
+	writeHeaderTest(t, filepath.Dir(path), "package.yml", headerV2)
+	if err := CheckRelease(path, false); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+}
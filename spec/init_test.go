@@ -0,0 +1,92 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fillTemplate replaces each placeholder in a package.yml, one per call
+var fillTemplate = []struct{ old, new string }{
+	{"Name-Of-Package", "foo"},
+	{"1.0.0a", "1.0"},
+	{"URI: HASH", "https://example.com/foo-1.0.tar.gz: 0123456789abcdef"},
+	{"'GPL-2.0-or-later # CHECK AND/OR CHANGE ME'", "MIT"},
+	{"'# SET ME'", "system.utils"},
+	{"'# Short description of the package'", "Foo"},
+	{"# Long description of the package", "Foo does things"},
+	{"    # Can be multiple lines\n", ""},
+	{"    # Just no 80/100 column wrapping!\n", ""},
+	{"# do some things", "%make_install"},
+	{"    # and stuff\n", ""},
+}
+
+func TestInitGuided(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "package.yml")
+	var calls int
+	guide := func(path string, remaining Problems) error {
+		if len(remaining) == 0 {
+			t.Fatalf("expected placeholders, found none")
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fill := fillTemplate[calls]
+		calls++
+		if !strings.Contains(string(data), fill.old) {
+			t.Fatalf("expected '%s', found: %s", fill.old, data)
+		}
+		return ioutil.WriteFile(path, []byte(strings.Replace(string(data), fill.old, fill.new, 1)), 0644)
+	}
+	pkg, err := InitGuided(path, guide)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	defer pkg.Close()
+	if calls != len(fillTemplate) {
+		t.Errorf("expected '%d', found: %d", len(fillTemplate), calls)
+	}
+	i, err := pkg.Convert()
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if i.Name != "foo" {
+		t.Errorf("expected '%s', found: %s", "foo", i.Name)
+	}
+}
+
+func TestInitGuidedStopped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "package.yml")
+	stop := errors.New("stopped")
+	_, err := InitGuided(path, func(string, Problems) error { return stop })
+	if !errors.Is(err, ErrPlaceholders) || !errors.Is(err, stop) {
+		t.Errorf("expected '%s', found: %v", ErrPlaceholders, err)
+	}
+}
+
+func TestInitGuidedNoProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "package.yml")
+	_, err := InitGuided(path, func(string, Problems) error { return nil })
+	if !errors.Is(err, ErrPlaceholders) || !errors.Is(err, ErrNoProgress) {
+		t.Errorf("expected '%s', found: %v", ErrNoProgress, err)
+	}
+}
@@ -0,0 +1,234 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"bufio"
+	"dev.getsol.us/source/libypkg.git/spec/internal"
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"strings"
+)
+
+// question is a single field asked for by InitInteractive
+type question struct {
+	// field is where the answer is stored, and which Problems belong to it
+	field string
+	// help explains what should be entered
+	help string
+	// rules are the lint Rules which check the field
+	rules []string
+	// value gets the current value of the field, which is offered as the default answer
+	value func(pkg *internal.PackageYML) string
+	// set stores an answer in the package, fetching any sources with "f"
+	set func(pkg *internal.PackageYML, f *shared.Fetcher, answer string) error
+}
+
+// questions are asked by InitInteractive, in order
+var questions = []question{
+	{
+		field: "name",
+		help:  "Name of the package, usually the same as the upstream project.",
+		rules: []string{"required-field", "template-placeholder", "invalid-name"},
+		value: func(pkg *internal.PackageYML) string { return pkg.Name },
+		set: func(pkg *internal.PackageYML, f *shared.Fetcher, answer string) error {
+			pkg.Name = answer
+			return nil
+		},
+	},
+	{
+		field: "version",
+		help:  "Version of the upstream release being packaged.",
+		rules: []string{"required-field", "template-placeholder", "invalid-version"},
+		value: func(pkg *internal.PackageYML) string { return pkg.Version },
+		set: func(pkg *internal.PackageYML, f *shared.Fetcher, answer string) error {
+			pkg.Version = answer
+			return nil
+		},
+	},
+	{
+		field: "source",
+		help:  "URIs of the sources, separated by spaces. Git repositories are written as 'git|URI:reference'.",
+		rules: []string{"required-field", "template-placeholder", "invalid-source"},
+		value: func(pkg *internal.PackageYML) string {
+			var URIs []string
+			for _, src := range pkg.Source {
+				URIs = append(URIs, src.String())
+			}
+			return strings.Join(URIs, " ")
+		},
+		set: func(pkg *internal.PackageYML, f *shared.Fetcher, answer string) error {
+			URIs := strings.Fields(answer)
+			if len(URIs) == 0 {
+				pkg.Source = nil
+				return nil
+			}
+			// Every source is downloaded to get its hash
+			return pkg.Update(pkg.Version, URIs, f)
+		},
+	},
+	{
+		field: "homepage",
+		help:  "Website of the upstream project.",
+		rules: []string{"invalid-homepage"},
+		value: func(pkg *internal.PackageYML) string { return pkg.Homepage },
+		set: func(pkg *internal.PackageYML, f *shared.Fetcher, answer string) error {
+			pkg.Homepage = answer
+			return nil
+		},
+	},
+	{
+		field: "license",
+		help:  "SPDX identifiers for the licenses of the package, separated by spaces.",
+		rules: []string{"required-field", "template-placeholder"},
+		value: func(pkg *internal.PackageYML) string {
+			var ids []string
+			for _, license := range pkg.License {
+				ids = append(ids, license.Value)
+			}
+			return strings.Join(ids, " ")
+		},
+		set: func(pkg *internal.PackageYML, f *shared.Fetcher, answer string) error {
+			var licenses shared.Licenses
+			for _, id := range strings.Fields(strings.ReplaceAll(answer, ",", " ")) {
+				licenses = append(licenses, yaml.Node{Kind: yaml.ScalarNode, Value: id})
+			}
+			pkg.License = licenses
+			return nil
+		},
+	},
+	{
+		field: "component",
+		help:  "Component the package belongs to, like 'system.utils'.",
+		rules: []string{"required-field", "template-placeholder"},
+		value: func(pkg *internal.PackageYML) string { return pkg.Component },
+		set: func(pkg *internal.PackageYML, f *shared.Fetcher, answer string) error {
+			pkg.Component = answer
+			return nil
+		},
+	},
+	{
+		field: "summary",
+		help:  "Short, single line description of the package.",
+		rules: []string{"required-field", "template-placeholder"},
+		value: func(pkg *internal.PackageYML) string { return pkg.Summary },
+		set: func(pkg *internal.PackageYML, f *shared.Fetcher, answer string) error {
+			pkg.Summary = answer
+			return nil
+		},
+	},
+	{
+		field: "description",
+		help:  "Longer description of the package.",
+		rules: []string{"required-field", "template-placeholder"},
+		value: func(pkg *internal.PackageYML) string { return strings.TrimSpace(pkg.Description) },
+		set: func(pkg *internal.PackageYML, f *shared.Fetcher, answer string) error {
+			pkg.Description = answer
+			return nil
+		},
+	},
+}
+
+// problems lints a package with the Rules for a question, keeping only the Problems with its field
+func (q question) problems(pkg *internal.PackageYML) (ps Problems) {
+	all, _ := pkg.LintRules(q.rules...).(Problems)
+	for _, p := range all {
+		if p.Field == q.field || strings.HasPrefix(p.Field, q.field+".") {
+			ps = append(ps, p)
+		}
+	}
+	return
+}
+
+// ask keeps asking a question until the answer passes the lint Rules for its field
+//
+// Warnings are shown, but do not stop an answer from being accepted.
+func (q question) ask(pkg *internal.PackageYML, f *shared.Fetcher, in *bufio.Reader, out io.Writer) error {
+	fmt.Fprintln(out, q.help)
+	for {
+		def := q.value(pkg)
+		if len(def) > 0 {
+			fmt.Fprintf(out, "%s [%s]: ", q.field, def)
+		} else {
+			fmt.Fprintf(out, "%s: ", q.field)
+		}
+		line, err := in.ReadString('\n')
+		if err == io.EOF && len(line) == 0 {
+			return fmt.Errorf("no answer for '%s': %w", q.field, io.ErrUnexpectedEOF)
+		} else if err != nil && err != io.EOF {
+			return err
+		}
+		answer := strings.TrimSpace(line)
+		if len(answer) == 0 {
+			answer = def
+		}
+		before := *pkg
+		if err = q.set(pkg, f, answer); err != nil {
+			fmt.Fprintf(out, "%s: %s: %s\n", SeverityError, q.field, err)
+			*pkg = before
+			continue
+		}
+		ps := q.problems(pkg)
+		for _, p := range ps {
+			fmt.Fprintln(out, p)
+		}
+		if len(ps.Errors()) == 0 {
+			return nil
+		}
+		*pkg = before
+	}
+}
+
+// InitInteractive creates a new package.yml like Init, asking for each field instead of writing placeholders
+//
+// The name, version and homepage detected from the first of the sources are offered as default answers.
+// Every answer is checked with the lint Rules for its field, and asked again until there are no errors.
+// The build stages are not asked for, so the install stage is left as a placeholder to be written.
+// Sources are fetched with "f", or the shared.DefaultFetcher if nil.
+func InitInteractive(path string, sources []string, f *shared.Fetcher, in io.Reader, out io.Writer) (pkg Package, err error) {
+	i := internal.NewPackage()
+	i.Release = 1
+	i.Stages = internal.Default().Stages
+	if len(sources) > 0 {
+		i.Detect(sources[0])
+	}
+	for _, src := range sources {
+		var s shared.SourceURI
+		if s, err = shared.ParseSourceURI(src); err != nil {
+			return
+		}
+		i.Source = append(i.Source, s)
+	}
+	r := bufio.NewReader(in)
+	for _, q := range questions {
+		if err = q.ask(i, f, r, out); err != nil {
+			return
+		}
+	}
+	if pkg, err = Init(path); err != nil {
+		return
+	}
+	if err = pkg.Modify(*i); err != nil {
+		return
+	}
+	for _, p := range i.Placeholders() {
+		fmt.Fprintln(out, p)
+	}
+	return
+}
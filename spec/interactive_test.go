@@ -0,0 +1,80 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// interactiveTest answers every question asked by InitInteractive for a local source
+func interactiveTest(t *testing.T, answers ...string) (Package, string, error) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "foo-1.0.tar.gz")
+	if err := ioutil.WriteFile(archive, []byte("foo"), 0644); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	in := strings.NewReader(strings.Join(answers, "\n"))
+	var out bytes.Buffer
+	pkg, err := InitInteractive(filepath.Join(dir, "package.yml"), []string{"file://" + archive}, nil, in, &out)
+	return pkg, out.String(), err
+}
+
+func TestInitInteractive(t *testing.T) {
+	pkg, out, err := interactiveTest(t, "foo bar", "", "", "", "example.com", "MIT", "", "system.utils", "Foo", "Foo does things\n")
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s\n%s", err, out)
+	}
+	defer pkg.Close()
+	for _, expected := range []string{
+		"name [foo]: ",
+		"error: name: 'foo bar' may only contain letters",
+		"version [1.0]: ",
+		"warning: homepage: homepage should be an HTTP(S) URL",
+		"error: component: missing required field",
+		"error: install:1: '# do some things' is a placeholder from the template and must be replaced (template-placeholder)",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected '%s', found: %s", expected, out)
+		}
+	}
+	i, err := pkg.Convert()
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if i.Name != "foo" || i.Version != "1.0" || i.Component != "system.utils" || i.Homepage != "example.com" {
+		t.Errorf("expected '%s', found: %s %s %s %s", "foo 1.0 system.utils example.com", i.Name, i.Version, i.Component, i.Homepage)
+	}
+	if len(i.Source) != 1 || i.Source[0].Digest.IsEmpty() {
+		t.Errorf("expected a hashed source, found: %v", i.Source)
+	}
+	if ps := i.Placeholders(); len(ps) != 2 {
+		t.Errorf("expected 2 placeholders, found: %v", ps)
+	}
+}
+
+func TestInitInteractiveEOF(t *testing.T) {
+	_, _, err := interactiveTest(t, "", "")
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected '%s', found: %v", io.ErrUnexpectedEOF, err)
+	}
+}
@@ -0,0 +1,115 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// archiveExtensions are removed from the names of source archives, longest first
+var archiveExtensions = []string{
+	".tar.bz2", ".tar.gz", ".tar.lz", ".tar.xz", ".tar.zst",
+	".tbz2", ".tgz", ".txz", ".tar", ".zip", ".git",
+}
+
+// forges host projects at "https://host/owner/project", which is used as the homepage
+var forges = map[string]bool{
+	"github.com":   true,
+	"gitlab.com":   true,
+	"codeberg.org": true,
+}
+
+// archivePattern splits the name of an archive into the project and its version, like "foo-bar-1.2.3"
+var archivePattern = regexp.MustCompile(`^(.+?)[-_]v?([0-9][0-9A-Za-z.+_~-]*)$`)
+
+// objectPattern matches a full or abbreviated Git object ID, which is never a version
+var objectPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// isObjectID checks if a Git reference looks like a commit hash rather than a tag
+//
+// Tags made only of digits, like dates, are still treated as versions.
+func isObjectID(ref string) bool {
+	return objectPattern.MatchString(ref) && strings.IndexAny(ref, "abcdef") >= 0
+}
+
+// tagPattern matches a Git tag or archive name which is only a version, like "v1.2.3"
+var tagPattern = regexp.MustCompile(`^(?:[A-Za-z]+[-_])?v?([0-9][0-9A-Za-z.+_~-]*)$`)
+
+// trimArchive removes the archive extension from the end of a filename
+func trimArchive(name string) string {
+	lower := strings.ToLower(name)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return name[:len(name)-len(ext)]
+		}
+	}
+	return name
+}
+
+// Detect fills out the name, version and homepage of the package by inspecting a source URI
+//
+// Only the URI itself is used, so nothing is downloaded. Fields which cannot be worked out are left unchanged,
+// including the homepage of any project not on a known forge, since the host is often just a download mirror.
+func (pkg *PackageYML) Detect(URI string) {
+	src, err := shared.ParseSourceURI(URI)
+	if err != nil {
+		return
+	}
+	u, err := url.Parse(src.URL)
+	if err != nil {
+		return
+	}
+	var name, version string
+	pieces := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if forges[u.Host] && len(pieces) >= 2 {
+		pkg.Homepage = "https://" + u.Host + "/" + pieces[0] + "/" + trimArchive(pieces[1])
+		name = trimArchive(pieces[1])
+	}
+	if src.IsGit() {
+		if m := tagPattern.FindStringSubmatch(src.Ref); m != nil && !isObjectID(src.Ref) {
+			version = m[1]
+		}
+		if len(name) == 0 {
+			name = trimArchive(path.Base(u.Path))
+		}
+	} else {
+		file := src.Rename
+		if len(file) == 0 {
+			file = path.Base(u.Path)
+		}
+		file = trimArchive(file)
+		if m := archivePattern.FindStringSubmatch(file); m != nil {
+			if len(name) == 0 {
+				name = m[1]
+			}
+			version = m[2]
+		} else if m := tagPattern.FindStringSubmatch(file); m != nil {
+			// Forges name tag archives after the tag alone, like "v1.2.3.tar.gz"
+			version = m[1]
+		}
+	}
+	if len(name) > 0 && namePattern.MatchString(name) {
+		pkg.Name = strings.ToLower(name)
+	}
+	if len(version) > 0 {
+		pkg.Version = version
+	}
+}
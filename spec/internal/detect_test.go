@@ -0,0 +1,52 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct{ URI, name, version, homepage string }{
+		{"https://ftp.gnu.org/gnu/make/make-4.3.tar.gz", "make", "4.3", ""},
+		{"https://example.com/dl/libfoo-bar_3.1.zip", "libfoo-bar", "3.1", ""},
+		{"https://example.com/foo-1.0.tar.gz#renamed-2.0.tgz", "renamed", "2.0", ""},
+		{"https://github.com/owner/Project/archive/refs/tags/v1.2.3.tar.gz", "project", "1.2.3", "https://github.com/owner/Project"},
+		{"https://github.com/owner/project/releases/download/v2.0/project-2.0.tar.xz", "project", "2.0", "https://github.com/owner/project"},
+		{"git|https://gitlab.com/owner/project.git:v0.4", "project", "0.4", "https://gitlab.com/owner/project"},
+		{"git|https://gitlab.com/owner/project.git:4f2e9a1c0b7d3e5f6a8b9c0d1e2f3a4b5c6d7e8f", "project", "", "https://gitlab.com/owner/project"},
+		{"git|https://example.com/project.git:4f2e9a1", "project", "", ""},
+		{"git|https://example.com/project.git:20210301", "project", "20210301", ""},
+		{"https://files.pythonhosted.org/packages/source/f/foo/foo-1.0.tar.gz", "foo", "1.0", ""},
+		{"file:///tmp/foo-1.0.tar.gz", "foo", "1.0", ""},
+	}
+	for _, test := range tests {
+		pkg := NewPackage()
+		pkg.Detect(test.URI)
+		if pkg.Name != test.name || pkg.Version != test.version || pkg.Homepage != test.homepage {
+			t.Errorf("expected '%s %s %s', found: %s %s %s", test.name, test.version, test.homepage, pkg.Name, pkg.Version, pkg.Homepage)
+		}
+	}
+}
+
+func TestDetectUnknown(t *testing.T) {
+	pkg := Default()
+	pkg.Detect("https://example.com/download?id=42")
+	if pkg.Name != placeholderName || pkg.Version != placeholderVersion {
+		t.Errorf("expected '%s %s', found: %s %s", placeholderName, placeholderVersion, pkg.Name, pkg.Version)
+	}
+}
@@ -0,0 +1,309 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"dev.getsol.us/source/libypkg.git/spec/shared/array"
+	"dev.getsol.us/source/libypkg.git/spec/shared/constant"
+	"dev.getsol.us/source/libypkg.git/spec/shared/diff"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Action is the kind of a Change
+type Action string
+
+const (
+	// Added means a value is only in the newer package
+	Added Action = "added"
+	// Removed means a value is only in the older package
+	Removed Action = "removed"
+	// Changed means a value is in both packages, but different
+	Changed Action = "changed"
+)
+
+// Change is a single semantic difference between two packages
+type Change struct {
+	// Field is the key that changed, with nested keys separated by dots like "deps.run" or "flags.clang"
+	Field string `json:"field"`
+	// Package is the subpackage the change applies to, or empty for the main package
+	Package string `json:"package,omitempty"`
+	Action  Action `json:"action"`
+	Old     string `json:"old,omitempty"`
+	New     string `json:"new,omitempty"`
+	// Patch is a unified diff of the changes to a script, like a build stage
+	Patch string `json:"patch,omitempty"`
+}
+
+// String summarizes this Change in a single line, followed by any Patch
+func (c Change) String() string {
+	field := c.Field
+	if len(c.Package) > 0 {
+		field += " (" + c.Package + ")"
+	}
+	switch {
+	case len(c.Patch) > 0:
+		return fmt.Sprintf("%s: %s\n%s", field, c.Action, strings.TrimSuffix(c.Patch, "\n"))
+	case c.Action == Added:
+		return fmt.Sprintf("%s: added %s", field, c.New)
+	case c.Action == Removed:
+		return fmt.Sprintf("%s: removed %s", field, c.Old)
+	}
+	return fmt.Sprintf("%s: %s -> %s", field, c.Old, c.New)
+}
+
+// Changes are all of the semantic differences between two packages
+type Changes []Change
+
+// String gets a report with one entry per Change
+func (cs Changes) String() string {
+	var lines []string
+	for _, c := range cs {
+		lines = append(lines, c.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON gets the Changes as an indented JSON array
+func (cs Changes) JSON() ([]byte, error) {
+	if cs == nil {
+		cs = Changes{}
+	}
+	return json.MarshalIndent(cs, "", "    ")
+}
+
+// scalar records a change to a single value
+func (cs *Changes) scalar(field, pkg, old, new string) {
+	switch {
+	case old == new:
+		return
+	case len(old) == 0:
+		*cs = append(*cs, Change{Field: field, Package: pkg, Action: Added, New: new})
+	case len(new) == 0:
+		*cs = append(*cs, Change{Field: field, Package: pkg, Action: Removed, Old: old})
+	default:
+		*cs = append(*cs, Change{Field: field, Package: pkg, Action: Changed, Old: old, New: new})
+	}
+}
+
+// set records every value added to or removed from a list, ignoring the order
+func (cs *Changes) set(field, pkg string, old, new []string) {
+	before := make(map[string]bool)
+	for _, v := range old {
+		before[v] = true
+	}
+	after := make(map[string]bool)
+	for _, v := range new {
+		after[v] = true
+	}
+	for _, v := range old {
+		if !after[v] {
+			*cs = append(*cs, Change{Field: field, Package: pkg, Action: Removed, Old: v})
+			after[v] = true
+		}
+	}
+	for _, v := range new {
+		if !before[v] {
+			*cs = append(*cs, Change{Field: field, Package: pkg, Action: Added, New: v})
+			before[v] = true
+		}
+	}
+}
+
+// script records a change to a multi-line script, with a Patch of the changed lines
+func (cs *Changes) script(field, old, new string) {
+	if old == new {
+		return
+	}
+	c := Change{
+		Field:  field,
+		Action: Changed,
+		Patch:  diff.Unified("a/"+field, "b/"+field, old, new, 3),
+	}
+	switch {
+	case len(old) == 0:
+		c.Action = Added
+	case len(new) == 0:
+		c.Action = Removed
+	}
+	*cs = append(*cs, c)
+}
+
+// subpackage gets the name of a subpackage for a Change, which is empty for the main package
+func subpackage(name string) string {
+	if name == constant.DefaultPackage {
+		return ""
+	}
+	return name
+}
+
+// values gets the values of a list of nodes
+func values(nodes []yaml.Node) (vs []string) {
+	for _, node := range nodes {
+		vs = append(vs, node.Value)
+	}
+	return
+}
+
+// listMap records the changes to each subpackage of a ListMap
+func (cs *Changes) listMap(field string, old, new array.ListMap) {
+	for _, name := range names(old, new) {
+		var before, after []string
+		for _, node := range old[name] {
+			before = append(before, node.Value)
+		}
+		for _, node := range new[name] {
+			after = append(after, node.Value)
+		}
+		cs.set(field, subpackage(name), before, after)
+	}
+}
+
+// namedMap records the changes to each subpackage of a Map
+func (cs *Changes) namedMap(field string, old, new array.Map) {
+	get := func(m array.Map, name string) string {
+		if node, ok := m[name]; ok && node != nil {
+			return node.Value
+		}
+		return ""
+	}
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []array.Map{old, new} {
+		for name := range m {
+			if !seen[name] {
+				seen[name] = true
+				keys = append(keys, name)
+			}
+		}
+	}
+	sort.Strings(keys)
+	for _, name := range keys {
+		cs.scalar(field, subpackage(name), get(old, name), get(new, name))
+	}
+}
+
+// names gets the subpackages in either ListMap, with the main package first
+func names(a, b array.ListMap) (all []string) {
+	seen := make(map[string]bool)
+	var main bool
+	for _, m := range []array.ListMap{a, b} {
+		for name := range m {
+			if name == constant.DefaultPackage {
+				main = true
+			} else if !seen[name] {
+				seen[name] = true
+				all = append(all, name)
+			}
+		}
+	}
+	sort.Strings(all)
+	if main {
+		all = append([]string{constant.DefaultPackage}, all...)
+	}
+	return
+}
+
+// sources records added and removed sources, and those with a new hash or reference
+func (cs *Changes) sources(old, new []shared.SourceURI) {
+	before := make(map[string]shared.SourceURI)
+	for _, src := range old {
+		before[src.Key()] = src
+	}
+	after := make(map[string]shared.SourceURI)
+	for _, src := range new {
+		after[src.Key()] = src
+	}
+	for _, src := range old {
+		if _, ok := after[src.Key()]; !ok {
+			*cs = append(*cs, Change{Field: "source", Action: Removed, Old: src.Key()})
+		}
+	}
+	for _, src := range new {
+		prev, ok := before[src.Key()]
+		switch {
+		case !ok:
+			*cs = append(*cs, Change{Field: "source", Action: Added, New: src.Key()})
+		case prev.Value() != src.Value():
+			*cs = append(*cs, Change{Field: "source", Action: Changed, Old: src.Key() + " : " + prev.Value(), New: src.Key() + " : " + src.Value()})
+		}
+	}
+}
+
+// flags records every flag that was toggled, and changes to the optimize values
+func (cs *Changes) flags(old, new BuildFlags) {
+	a, b := reflect.ValueOf(old), reflect.ValueOf(new)
+	t := a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := "flags." + strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		switch before := a.Field(i).Interface().(type) {
+		case shared.DefaultTrue:
+			cs.scalar(name, "", yesNo(before.Value()), yesNo(b.Field(i).Interface().(shared.DefaultTrue).Value()))
+		case shared.DefaultFalse:
+			cs.scalar(name, "", yesNo(before.Value()), yesNo(b.Field(i).Interface().(shared.DefaultFalse).Value()))
+		case []string:
+			cs.set(name, "", before, b.Field(i).Interface().([]string))
+		}
+	}
+}
+
+// yesNo writes a bool the way package.yml does
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// Diff finds the semantic differences between an older and a newer package
+//
+// Lists are compared as sets, so reordering them is not a change.
+func Diff(old, new *PackageYML) (cs Changes) {
+	cs.scalar("name", "", old.Name, new.Name)
+	cs.scalar("version", "", old.Version, new.Version)
+	cs.scalar("release", "", strconv.FormatUint(uint64(old.Release), 10), strconv.FormatUint(uint64(new.Release), 10))
+	cs.sources(old.Source, new.Source)
+	cs.scalar("homepage", "", old.Homepage, new.Homepage)
+	cs.set("license", "", values(old.License), values(new.License))
+	cs.scalar("component", "", old.Component, new.Component)
+	cs.namedMap("components", old.Components, new.Components)
+	cs.scalar("summary", "", old.Summary, new.Summary)
+	cs.namedMap("summaries", old.Summaries, new.Summaries)
+	cs.scalar("description", "", old.Description, new.Description)
+	cs.namedMap("descriptions", old.Descriptions, new.Descriptions)
+	cs.listMap("deps.replaces", old.Dependencies.Replaces, new.Dependencies.Replaces)
+	cs.listMap("deps.conflicts", old.Dependencies.Conflicts, new.Dependencies.Conflicts)
+	cs.set("deps.build", "", values(old.Dependencies.Build), values(new.Dependencies.Build))
+	cs.set("deps.check", "", values(old.Dependencies.Check), values(new.Dependencies.Check))
+	cs.listMap("deps.run", old.Dependencies.Run, new.Dependencies.Run)
+	cs.flags(old.Flags, new.Flags)
+	cs.script("environment", old.Environment, new.Environment)
+	cs.script("setup", old.Stages.Setup, new.Stages.Setup)
+	cs.script("build", old.Stages.Build, new.Stages.Build)
+	cs.script("profile", old.Stages.Profile, new.Stages.Profile)
+	cs.script("check", old.Stages.Check, new.Stages.Check)
+	cs.script("install", old.Stages.Install, new.Stages.Install)
+	cs.listMap("permanent", old.Permanent, new.Permanent)
+	cs.listMap("patterns", old.Patterns, new.Patterns)
+	return
+}
@@ -0,0 +1,211 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+// Doc describes the meaning of a single field of a PackageYML
+type Doc struct {
+	// Description explains what the field is for, in one or two sentences
+	Description string
+	// Example is a typical value for the field, written as YAML
+	Example string
+	// Default is the value used when the field is missing, if it has one
+	Default string
+	// Values are the only values allowed for the field, or every item in a list
+	Values []string
+}
+
+// Docs describe every field of a PackageYML, by the dotted path of its key like "flags.emul32"
+var Docs = map[string]Doc{
+	"YPKG": {
+		Description: "Version of the package.yml format.",
+		Example:     "3",
+		Default:     "2",
+	},
+	"name": {
+		Description: "Name of the package, usually the same as the upstream project.",
+		Example:     "nano",
+	},
+	"version": {
+		Description: "Version of the upstream release being packaged.",
+		Example:     "5.6.1",
+	},
+	"release": {
+		Description: "Release number of the package, which must be increased for every change.",
+		Example:     "142",
+	},
+	"source": {
+		Description: "Archives to download as 'URI : hash', or Git repositories as 'git|URI : reference'.",
+		Example:     "[https://www.nano-editor.org/dist/v5/nano-5.6.1.tar.xz : 760d7059e0881ca0ee7e2a33b09d999ec456ff7204df86bee58eb6f247f6c4ef]",
+	},
+	"homepage": {
+		Description: "Website of the upstream project.",
+		Example:     "https://www.nano-editor.org",
+	},
+	"license": {
+		Description: "SPDX identifiers for the licenses of the package.",
+		Example:     "GPL-3.0-or-later",
+	},
+	"component": {
+		Description: "Component the package belongs to.",
+		Example:     "editor",
+	},
+	"components": {
+		Description: "Components of each subpackage, starting with the main package.",
+		Example:     "[editor, docs: programming.docs]",
+	},
+	"summary": {
+		Description: "Short, single line description of the package.",
+		Example:     "Small, friendly text editor",
+	},
+	"summaries": {
+		Description: "Summaries of each subpackage, starting with the main package.",
+		Example:     "['Small, friendly text editor', docs: Documentation for nano]",
+	},
+	"description": {
+		Description: "Longer description of the package.",
+		Example:     "GNU nano is an easy-to-use text editor originally designed as a replacement for Pico.",
+	},
+	"descriptions": {
+		Description: "Descriptions of each subpackage, starting with the main package.",
+		Example:     "[GNU nano is an easy-to-use text editor., docs: Documentation for nano]",
+	},
+	"deps": {
+		Description: "Dependencies of the package.",
+		Example:     "{build: [pkgconfig(ncursesw)]}",
+	},
+	"deps.replaces": {
+		Description: "Packages which are replaced by this one, optionally grouped by subpackage.",
+		Example:     "[nano-tiny]",
+	},
+	"deps.conflicts": {
+		Description: "Packages which cannot be installed at the same time as this one, optionally grouped by subpackage.",
+		Example:     "[pico]",
+	},
+	"deps.build": {
+		Description: "Packages needed to build this one.",
+		Example:     "[pkgconfig(ncursesw)]",
+	},
+	"deps.check": {
+		Description: "Packages needed to run the tests of this one.",
+		Example:     "[python3]",
+	},
+	"deps.run": {
+		Description: "Packages needed at runtime which are not detected automatically, optionally grouped by subpackage.",
+		Example:     "[file, devel: [ncurses-devel]]",
+	},
+	"flags": {
+		Description: "Options which change how the package is built.",
+		Example:     "{clang: no, optimize: [speed]}",
+	},
+	"flags.autodep": {
+		Description: "Detect the runtime dependencies of the package automatically.",
+		Example:     "no",
+		Default:     "yes",
+	},
+	"flags.avx2": {
+		Description: "Build an extra set of libraries optimized for AVX2 capable processors.",
+		Example:     "yes",
+		Default:     "no",
+	},
+	"flags.clang": {
+		Description: "Build with the clang toolchain rather than gcc.",
+		Example:     "no",
+		Default:     "yes",
+	},
+	"flags.ccache": {
+		Description: "Cache compiler output with ccache to speed up rebuilds.",
+		Example:     "yes",
+		Default:     "no",
+	},
+	"flags.debug": {
+		Description: "Split debug symbols into a separate dbginfo package.",
+		Example:     "no",
+		Default:     "yes",
+	},
+	"flags.devel": {
+		Description: "Put the whole package in the system.devel component.",
+		Example:     "yes",
+		Default:     "no",
+	},
+	"flags.emul32": {
+		Description: "Build an extra set of 32-bit libraries into a 32bit subpackage.",
+		Example:     "yes",
+		Default:     "no",
+	},
+	"flags.extract": {
+		Description: "Extract the sources into the work directory before building.",
+		Example:     "no",
+		Default:     "yes",
+	},
+	"flags.lastrip": {
+		Description: "Remove the libtool .la files of installed libraries.",
+		Example:     "no",
+		Default:     "yes",
+	},
+	"flags.libsplit": {
+		Description: "Split headers and unversioned libraries into a devel subpackage.",
+		Example:     "no",
+		Default:     "yes",
+	},
+	"flags.networking": {
+		Description: "Allow network access while building.",
+		Example:     "yes",
+		Default:     "no",
+	},
+	"flags.optimize": {
+		Description: "Extra optimizations applied to the compiler and linker flags.",
+		Example:     "[speed, lto]",
+		Values:      Optimizations,
+	},
+	"flags.strip": {
+		Description: "Strip the symbols from installed binaries.",
+		Example:     "no",
+		Default:     "yes",
+	},
+	"environment": {
+		Description: "Shell code run at the start of every build stage.",
+		Example:     "export CFLAGS=\"$CFLAGS -fcommon\"",
+	},
+	"setup": {
+		Description: "Build stage which configures the sources.",
+		Example:     "'%configure --enable-utf8'",
+	},
+	"build": {
+		Description: "Build stage which compiles the sources.",
+		Example:     "'%make'",
+	},
+	"profile": {
+		Description: "Build stage which runs a workload to generate a profile for PGO builds.",
+		Example:     "'%make check'",
+	},
+	"check": {
+		Description: "Build stage which runs the tests of the package.",
+		Example:     "'%make check'",
+	},
+	"install": {
+		Description: "Build stage which installs the files of the package into $installdir.",
+		Example:     "'%make_install'",
+	},
+	"permanent": {
+		Description: "Paths which are never removed when the package is upgraded, optionally grouped by subpackage.",
+		Example:     "[/usr/lib/modules]",
+	},
+	"patterns": {
+		Description: "Paths which are moved into subpackages, grouped by subpackage.",
+		Example:     "[devel: [/usr/include], docs: [/usr/share/doc]]",
+	},
+}
@@ -0,0 +1,297 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared/shell"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrComplexAssignment indicates a variable which is set on a line with other commands, so it cannot be edited alone
+	ErrComplexAssignment = errors.New("variable is not set on a line of its own")
+	// ErrInvalidVariable indicates a name which cannot be used for a shell variable
+	ErrInvalidVariable = errors.New("invalid variable name")
+)
+
+// variablePattern matches the names allowed for shell variables
+var variablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Variable is a single assignment in the environment of a package
+type Variable struct {
+	// Name is the name of the variable
+	Name string
+	// Value is the value as it is written, including any quotes
+	Value string
+	// Export is set if the variable is exported to the commands of every stage
+	Export bool
+	// Line is the line of the environment where the variable is set, starting at 1
+	Line int
+}
+
+// Unquoted gets the Value of this Variable without any surrounding quotes
+func (v Variable) Unquoted() string {
+	value := v.Value
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return value
+}
+
+// References checks if the Value of this Variable uses its previous value, like CFLAGS="$CFLAGS -fcommon"
+//
+// Other variables starting with the same name, like $CFLAGS_EXTRA, are not references.
+func (v Variable) References() bool {
+	pattern := regexp.MustCompile(`\$\{?` + regexp.QuoteMeta(v.Name) + `([^A-Za-z0-9_]|$)`)
+	return pattern.MatchString(v.Value)
+}
+
+// Environment is the parsed form of the environment of a package, which is run before every stage
+type Environment struct {
+	// Variables are every assignment, in the order they are made
+	Variables []Variable
+	lines     []string
+}
+
+// ParseEnvironment finds every variable assignment and export in the environment of a package
+//
+// Assignments which only apply to a single command, like "CC=gcc make", are not included.
+func ParseEnvironment(script string) *Environment {
+	e := &Environment{
+		lines: strings.Split(script, "\n"),
+	}
+	for _, cmd := range shell.Parse(script) {
+		export := cmd.Words[0].Text == "export" || cmd.Words[0].Text == "declare" && len(cmd.Words) > 1 && cmd.Words[1].Text == "-x"
+		args := cmd.Args()
+		if !export && len(args) > 0 {
+			continue
+		}
+		for _, w := range cmd.Words {
+			if !w.IsAssignment() {
+				if export && variablePattern.MatchString(w.Text) {
+					e.markExported(w.Text)
+				}
+				continue
+			}
+			i := strings.Index(w.Text, "=")
+			e.Variables = append(e.Variables, Variable{
+				Name:   w.Text[:i],
+				Value:  w.Text[i+1:],
+				Export: export || e.exported(w.Text[:i]),
+				Line:   cmd.Line,
+			})
+		}
+	}
+	return e
+}
+
+// markExported exports every earlier assignment of a variable
+func (e *Environment) markExported(name string) {
+	for i := range e.Variables {
+		if e.Variables[i].Name == name {
+			e.Variables[i].Export = true
+		}
+	}
+}
+
+// exported checks if a variable has already been exported
+func (e *Environment) exported(name string) bool {
+	v, ok := e.Get(name)
+	return ok && v.Export
+}
+
+// Get finds the last assignment of a variable
+func (e *Environment) Get(name string) (v Variable, ok bool) {
+	for _, candidate := range e.Variables {
+		if candidate.Name == name {
+			v, ok = candidate, true
+		}
+	}
+	return
+}
+
+// simple checks if a line does nothing other than set a single variable
+func (e *Environment) simple(v Variable) bool {
+	cmds := shell.Parse(e.lines[v.Line-1])
+	if len(cmds) != 1 {
+		return false
+	}
+	words := cmds[0].Words
+	if words[0].Text == "export" {
+		words = words[1:]
+	}
+	return len(words) == 1 && strings.HasPrefix(words[0].Text, v.Name+"=")
+}
+
+// exportedNames gets the variables of a line which does nothing other than export them, like "export CC CXX"
+func exportedNames(line string) (names []string, ok bool) {
+	cmds := shell.Parse(line)
+	if len(cmds) != 1 || cmds[0].Words[0].Text != "export" || len(cmds[0].Words) == 1 {
+		return
+	}
+	for _, w := range cmds[0].Words[1:] {
+		if !variablePattern.MatchString(w.Text) {
+			return nil, false
+		}
+		names = append(names, w.Text)
+	}
+	return names, true
+}
+
+// Set changes the value of a variable, or exports a new one at the end of the environment
+//
+// The value is written as it is, so it must already be quoted for the shell. Only the line of the
+// last assignment is changed, keeping its indentation and whether it is exported.
+func (e *Environment) Set(name, value string) error {
+	if !variablePattern.MatchString(name) {
+		return fmt.Errorf("%w: '%s'", ErrInvalidVariable, name)
+	}
+	v, ok := e.Get(name)
+	if !ok {
+		line := "export " + name + "=" + value
+		// Keep the trailing newline of a block scalar at the end
+		if n := len(e.lines); len(strings.TrimSpace(e.lines[n-1])) == 0 {
+			e.lines = append(e.lines[:n-1], line, e.lines[n-1])
+		} else {
+			e.lines = append(e.lines, line)
+		}
+		e.reparse()
+		return nil
+	}
+	if !e.simple(v) {
+		return fmt.Errorf("%w: '%s' on line %d", ErrComplexAssignment, name, v.Line)
+	}
+	line := e.lines[v.Line-1]
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	if strings.HasPrefix(strings.TrimSpace(line), "export ") {
+		indent += "export "
+	}
+	e.lines[v.Line-1] = indent + name + "=" + value
+	e.reparse()
+	return nil
+}
+
+// Unset removes every assignment of a variable, along with any separate export of it
+func (e *Environment) Unset(name string) error {
+	remove := make(map[int]bool)
+	for _, v := range e.Variables {
+		if v.Name != name {
+			continue
+		}
+		if !e.simple(v) {
+			return fmt.Errorf("%w: '%s' on line %d", ErrComplexAssignment, name, v.Line)
+		}
+		remove[v.Line-1] = true
+	}
+	for i, line := range e.lines {
+		if remove[i] {
+			continue
+		}
+		names, ok := exportedNames(line)
+		if !ok {
+			continue
+		}
+		var keep []string
+		for _, n := range names {
+			if n != name {
+				keep = append(keep, n)
+			}
+		}
+		switch {
+		case len(keep) == len(names):
+		case len(keep) == 0:
+			remove[i] = true
+		default:
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			e.lines[i] = indent + "export " + strings.Join(keep, " ")
+		}
+	}
+	var lines []string
+	for i, line := range e.lines {
+		if !remove[i] {
+			lines = append(lines, line)
+		}
+	}
+	e.lines = lines
+	e.reparse()
+	return nil
+}
+
+// reparse finds the Variables again after the lines have changed
+func (e *Environment) reparse() {
+	*e = *ParseEnvironment(e.String())
+}
+
+// String gets the environment as it is written in package.yml
+func (e *Environment) String() string {
+	return strings.Join(e.lines, "\n")
+}
+
+// Override is a Variable which replaces something decided by the BuildFlags of a package
+type Override struct {
+	Variable
+	// Reason explains what is being replaced
+	Reason string
+}
+
+// Overrides finds every Variable which replaces the flags or compilers chosen by the BuildFlags
+//
+// These variables are already exported by ypkg, so they are replaced even without an export. Flags
+// which extend their previous value, like CFLAGS="$CFLAGS -fcommon", are not Overrides.
+func (e *Environment) Overrides(flags BuildFlags) (overrides []Override) {
+	for _, v := range e.Variables {
+		var reason string
+		switch v.Name {
+		case "CFLAGS", "CXXFLAGS", "LDFLAGS":
+			if v.References() {
+				continue
+			}
+			reason = "replaces the distribution " + v.Name
+			if len(flags.Optimize) > 0 {
+				reason += fmt.Sprintf(", including those from 'optimize: [%s]'", strings.Join(flags.Optimize, ", "))
+			}
+		case "CC", "CXX":
+			value := v.Unquoted()
+			clang := strings.Contains(value, "clang")
+			gcc := strings.Contains(value, "gcc") || strings.Contains(value, "g++")
+			switch {
+			case flags.Clang.Value() && gcc:
+				reason = "uses gcc instead of setting 'clang: no'"
+			case !flags.Clang.Value() && clang:
+				reason = "uses clang while 'clang: no' is set"
+			default:
+				continue
+			}
+		default:
+			continue
+		}
+		overrides = append(overrides, Override{Variable: v, Reason: reason})
+	}
+	return
+}
+
+// checkOverrides finds variables in the environment which replace what is decided by the BuildFlags
+func checkOverrides(pkg *PackageYML) (ps Problems) {
+	for _, o := range ParseEnvironment(pkg.Environment).Overrides(pkg.Flags) {
+		msg := fmt.Sprintf("setting %s %s", o.Name, o.Reason)
+		ps = append(ps, Problem{Field: "environment", Line: o.Line, Severity: Warning, Message: msg})
+	}
+	return
+}
@@ -0,0 +1,148 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+const testEnvironment = `export CFLAGS="$CFLAGS -fcommon"
+LDFLAGS="-Wl,-O1"
+export LDFLAGS
+    export CC=gcc
+PYTHON=python3 ./configure
+export A=1 B=2
+`
+
+func TestParseEnvironment(t *testing.T) {
+	e := ParseEnvironment(testEnvironment)
+	expected := []Variable{
+		{"CFLAGS", `"$CFLAGS -fcommon"`, true, 1},
+		{"LDFLAGS", `"-Wl,-O1"`, true, 2},
+		{"CC", "gcc", true, 4},
+		{"A", "1", true, 6},
+		{"B", "2", true, 6},
+	}
+	if len(e.Variables) != len(expected) {
+		t.Fatalf("expected %d variables, found: %v", len(expected), e.Variables)
+	}
+	for i, v := range e.Variables {
+		if v != expected[i] {
+			t.Errorf("expected '%v', found: %v", expected[i], v)
+		}
+	}
+	if v, _ := e.Get("LDFLAGS"); v.Unquoted() != "-Wl,-O1" {
+		t.Errorf("expected '%s', found: %s", "-Wl,-O1", v.Unquoted())
+	}
+}
+
+func TestEnvironmentSet(t *testing.T) {
+	e := ParseEnvironment(testEnvironment)
+	if err := e.Set("CC", "clang"); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if err := e.Set("LDFLAGS", `"$LDFLAGS -Wl,-O1"`); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if err := e.Set("GOPATH", `"$workdir/go"`); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if err := e.Unset("CFLAGS"); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	expected := `LDFLAGS="$LDFLAGS -Wl,-O1"
+export LDFLAGS
+    export CC=clang
+PYTHON=python3 ./configure
+export A=1 B=2
+export GOPATH="$workdir/go"
+`
+	if result := e.String(); result != expected {
+		t.Errorf("expected '%s', found: %s", expected, result)
+	}
+	if v, ok := e.Get("GOPATH"); !ok || v.Line != 6 || !v.Export {
+		t.Errorf("expected '%s' on line %d, found: %v", "GOPATH", 6, v)
+	}
+	if err := e.Set("A", "3"); !errors.Is(err, ErrComplexAssignment) {
+		t.Errorf("expected '%s', found: %v", ErrComplexAssignment, err)
+	}
+	if err := e.Unset("B"); !errors.Is(err, ErrComplexAssignment) {
+		t.Errorf("expected '%s', found: %v", ErrComplexAssignment, err)
+	}
+	if err := e.Set("NOT-A-NAME", "1"); !errors.Is(err, ErrInvalidVariable) {
+		t.Errorf("expected '%s', found: %v", ErrInvalidVariable, err)
+	}
+}
+
+func TestEnvironmentUnset(t *testing.T) {
+	e := ParseEnvironment(testEnvironment + "export CC LDFLAGS\n")
+	if err := e.Unset("LDFLAGS"); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	expected := `export CFLAGS="$CFLAGS -fcommon"
+    export CC=gcc
+PYTHON=python3 ./configure
+export A=1 B=2
+export CC
+`
+	if result := e.String(); result != expected {
+		t.Errorf("expected '%s', found: %s", expected, result)
+	}
+}
+
+func TestVariableReferences(t *testing.T) {
+	for value, expected := range map[string]bool{
+		`"$CFLAGS -fcommon"`:     true,
+		`"${CFLAGS} -fcommon"`:   true,
+		`"-fcommon $CFLAGS"`:     true,
+		`"${CFLAGS:-} -fcommon"`: true,
+		`"$CFLAGS_EXTRA -O2"`:    false,
+		`"${CFLAGS_EXTRA} -O2"`:  false,
+		`"$CXXFLAGS -O2"`:        false,
+	} {
+		v := Variable{Name: "CFLAGS", Value: value}
+		if found := v.References(); found != expected {
+			t.Errorf("expected '%t' for '%s', found: %t", expected, value, found)
+		}
+	}
+}
+
+func TestEnvironmentOverrides(t *testing.T) {
+	pkg := lintable(t)
+	pkg.Environment = testEnvironment
+	pkg.Flags.Optimize = []string{"speed"}
+	all, _ := pkg.Lint().(Problems)
+	var ps Problems
+	for _, p := range all {
+		if p.Rule == "environment-override" {
+			ps = append(ps, p)
+		}
+	}
+	if len(ps) != 2 {
+		t.Fatalf("expected 2 problems, found: %v", all)
+	}
+	expected := []string{
+		"setting LDFLAGS replaces the distribution LDFLAGS, including those from 'optimize: [speed]'",
+		"setting CC uses gcc instead of setting 'clang: no'",
+	}
+	for i, p := range ps {
+		if p.Message != expected[i] {
+			t.Errorf("expected '%s', found: %s", expected[i], p)
+		}
+	}
+}
@@ -36,3 +36,22 @@ type BuildFlags struct {
 	Optimize   []string            `yaml:"optimize,omitempty"`
 	Strip      shared.DefaultTrue  `yaml:"strip,omitempty"`
 }
+
+// Optimizations are the values understood by ypkg for BuildFlags.Optimize
+var Optimizations = []string{
+	"speed",
+	"size",
+	"lto",
+	"thin-lto",
+	"unroll-loops",
+	"no-bind-now",
+	"no-symbolic",
+	"no-frame-pointer",
+	"runpath",
+	"avx256",
+	"sse4",
+	"icf-safe",
+	"icf-all",
+	"polly",
+	"function-sections",
+}
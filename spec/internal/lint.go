@@ -0,0 +1,248 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"dev.getsol.us/source/libypkg.git/spec/shared/version"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Severity is how serious a Problem is
+type Severity int
+
+const (
+	// Error is a Problem which will stop the package from building or being accepted
+	Error Severity = iota
+	// Warning is a questionable choice which should be reviewed
+	Warning
+)
+
+// String gets a human readable name for this Severity
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Problem is a single issue found by Lint
+type Problem struct {
+	// Rule is the name of the Rule which found the Problem
+	Rule string
+	// Severity is how serious the Problem is
+	Severity Severity
+	// Field is the key with the Problem, with nested keys separated by dots like "flags.optimize"
+	Field string
+	// Line is the line within the value of Field, starting at 1, or 0 for the key itself
+	Line int
+	// Message describes the Problem
+	Message string
+}
+
+// String summarizes this Problem in a single line
+func (p Problem) String() string {
+	field := p.Field
+	if p.Line > 0 {
+		field = fmt.Sprintf("%s:%d", field, p.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s (%s)", p.Severity, field, p.Message, p.Rule)
+}
+
+// Problems are all of the issues found by Lint
+type Problems []Problem
+
+// Error lists every Problem, one per line
+func (ps Problems) Error() string {
+	var lines []string
+	for _, p := range ps {
+		lines = append(lines, p.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Errors gets only the Problems with the Error Severity
+func (ps Problems) Errors() (errs Problems) {
+	for _, p := range ps {
+		if p.Severity == Error {
+			errs = append(errs, p)
+		}
+	}
+	return
+}
+
+// Rule is a single check made by Lint
+type Rule struct {
+	// Name identifies the Rule in each Problem it finds
+	Name string
+	// Check finds every Problem this Rule cares about
+	Check func(pkg *PackageYML) Problems
+}
+
+// Rules are every check made by Lint, in order
+var Rules = []Rule{
+	{"required-field", checkRequired},
+	{"template-placeholder", checkPlaceholders},
+	{"invalid-name", checkName},
+	{"invalid-version", checkVersion},
+	{"invalid-source", checkSources},
+	{"invalid-homepage", checkHomepage},
+	{"invalid-optimize", checkOptimize},
+	{"unknown-macro", checkMacros},
+	{"invalid-script", checkSyntax},
+	{"prefer-macro", checkRawCommands},
+	{"cd-without-workdir", checkCd},
+	{"unquoted-rm", checkRm},
+	{"hardcoded-libdir", checkLibdir},
+	{"environment-override", checkOverrides},
+}
+
+// Lint checks over the package for any obvious errors or questionable choices
+//
+// Every Problem found by the Rules is returned as Problems, or nil if there are none.
+func (pkg *PackageYML) Lint() error {
+	return pkg.lint(Rules)
+}
+
+// LintRules checks over the package with only the named Rules, in the order of Rules
+//
+// This is much cheaper than Lint when only a few fields need checking, since the build stages are not expanded and parsed for their syntax.
+func (pkg *PackageYML) LintRules(names ...string) error {
+	var rules []Rule
+	for _, rule := range Rules {
+		for _, name := range names {
+			if rule.Name == name {
+				rules = append(rules, rule)
+				break
+			}
+		}
+	}
+	return pkg.lint(rules)
+}
+
+// lint collects every Problem found by the rules
+func (pkg *PackageYML) lint(rules []Rule) error {
+	var ps Problems
+	for _, rule := range rules {
+		for _, p := range rule.Check(pkg) {
+			p.Rule = rule.Name
+			ps = append(ps, p)
+		}
+	}
+	if len(ps) == 0 {
+		return nil
+	}
+	return ps
+}
+
+// checkRequired makes sure that every required field is set
+func checkRequired(pkg *PackageYML) (ps Problems) {
+	missing := func(field string, empty bool) {
+		if empty {
+			msg := "missing required field"
+			if doc, ok := Docs[field]; ok {
+				msg += ": " + strings.TrimSuffix(doc.Description, ".")
+			}
+			ps = append(ps, Problem{Field: field, Message: msg})
+		}
+	}
+	missing("name", len(pkg.Name) == 0)
+	missing("version", len(pkg.Version) == 0)
+	missing("release", pkg.Release == 0)
+	missing("source", len(pkg.Source) == 0)
+	missing("license", len(pkg.License) == 0)
+	missing("component", len(pkg.Component) == 0 && len(pkg.Components) == 0)
+	missing("summary", len(pkg.Summary) == 0 && len(pkg.Summaries) == 0)
+	missing("description", len(pkg.Description) == 0 && len(pkg.Descriptions) == 0)
+	missing("install", len(strings.TrimSpace(pkg.Stages.Install)) == 0)
+	return
+}
+
+// namePattern matches the names allowed for packages
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9+._-]*$`)
+
+// checkName makes sure that the name of the package is allowed
+func checkName(pkg *PackageYML) (ps Problems) {
+	if len(pkg.Name) > 0 && !namePattern.MatchString(pkg.Name) {
+		ps = append(ps, Problem{Field: "name", Message: fmt.Sprintf("'%s' may only contain letters, digits and '+', '.', '_' or '-'", pkg.Name)})
+	}
+	return
+}
+
+// checkVersion makes sure that the version can be compared with other versions
+func checkVersion(pkg *PackageYML) (ps Problems) {
+	if len(pkg.Version) == 0 {
+		return
+	}
+	if _, err := version.Parse(pkg.Version); err != nil {
+		ps = append(ps, Problem{Field: "version", Message: fmt.Sprintf("'%s': %s", pkg.Version, err)})
+	}
+	return
+}
+
+// checkSources makes sure that every source is complete, supported and listed once
+func checkSources(pkg *PackageYML) (ps Problems) {
+	seen := make(map[string]bool)
+	for i, src := range pkg.Source {
+		line := i + 1
+		bad := func(format string, args ...interface{}) {
+			ps = append(ps, Problem{Field: "source", Line: line, Message: fmt.Sprintf(format, args...)})
+		}
+		if seen[src.Key()] {
+			p := Problem{Field: "source", Line: line, Severity: Warning, Message: fmt.Sprintf("'%s' is listed more than once", src.Key())}
+			ps = append(ps, p)
+		}
+		seen[src.Key()] = true
+		switch src.Scheme {
+		case shared.GitScheme:
+			if len(src.Ref) == 0 {
+				bad("'%s' is missing a Git reference", src.URL)
+			}
+		case "http", "https", "file":
+			if src.Digest.IsEmpty() {
+				bad("'%s' is missing a hash", src.URL)
+			} else if _, err := src.Digest.Algorithm.New(); err != nil {
+				bad("'%s': %s", src.URL, err)
+			}
+		default:
+			bad("'%s': %s", src.Key(), shared.ErrUnsupportedSource)
+		}
+	}
+	return
+}
+
+// checkHomepage makes sure that the homepage is a web address
+func checkHomepage(pkg *PackageYML) (ps Problems) {
+	if len(pkg.Homepage) > 0 && !strings.HasPrefix(pkg.Homepage, "https://") && !strings.HasPrefix(pkg.Homepage, "http://") {
+		ps = append(ps, Problem{Field: "homepage", Severity: Warning, Message: "homepage should be an HTTP(S) URL"})
+	}
+	return
+}
+
+// checkOptimize makes sure that every optimize value is known and can be used with the compiler
+func checkOptimize(pkg *PackageYML) (ps Problems) {
+	for i, name := range pkg.Flags.Optimize {
+		flags := pkg.Flags
+		flags.Optimize = []string{name}
+		if _, err := flags.Resolve(Native); err != nil {
+			ps = append(ps, Problem{Field: "flags.optimize", Line: i + 1, Message: err.Error()})
+		}
+	}
+	return
+}
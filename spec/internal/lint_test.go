@@ -0,0 +1,154 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"gopkg.in/yaml.v3"
+	"strings"
+	"testing"
+)
+
+// lintable creates a package which passes every Rule
+func lintable(t *testing.T) *PackageYML {
+	src, err := shared.ParseSourceURI("https://example.com/foo-1.0.tar.gz")
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	src.Digest = shared.ParseDigest("0123456789abcdef")
+	pkg := NewPackage()
+	pkg.Name = "foo"
+	pkg.Version = "1.0"
+	pkg.Release = 1
+	pkg.Source = []shared.SourceURI{src}
+	pkg.Homepage = "https://example.com"
+	pkg.License = shared.Licenses{{Kind: yaml.ScalarNode, Value: "MIT"}}
+	pkg.Component = "system.utils"
+	pkg.Summary = "Foo"
+	pkg.Description = "Foo does things"
+	pkg.Stages.Install = "%make_install"
+	return pkg
+}
+
+func TestLintClean(t *testing.T) {
+	if err := lintable(t).Lint(); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+}
+
+func TestLintProblems(t *testing.T) {
+	pkg := lintable(t)
+	pkg.Name = "foo bar"
+	pkg.Version = ""
+	pkg.Homepage = "example.com"
+	pkg.Source = append(pkg.Source, pkg.Source[0])
+	err := pkg.Lint()
+	ps, ok := err.(Problems)
+	if !ok {
+		t.Fatalf("Expected Problems, found: %v", err)
+	}
+	expected := []struct {
+		rule, field string
+		line        int
+		severity    Severity
+	}{
+		{"required-field", "version", 0, Error},
+		{"invalid-name", "name", 0, Error},
+		{"invalid-source", "source", 2, Warning},
+		{"invalid-homepage", "homepage", 0, Warning},
+	}
+	if len(ps) != len(expected) {
+		t.Fatalf("expected %d problems, found: %s", len(expected), ps)
+	}
+	for i, e := range expected {
+		p := ps[i]
+		if p.Rule != e.rule || p.Field != e.field || p.Line != e.line || p.Severity != e.severity {
+			t.Errorf("expected '%s' on '%s:%d', found: %s", e.rule, e.field, e.line, p)
+		}
+	}
+	if errs := ps.Errors(); len(errs) != 2 {
+		t.Errorf("expected 2 errors, found: %s", errs)
+	}
+}
+
+func TestLintRules(t *testing.T) {
+	pkg := lintable(t)
+	pkg.Name = "foo bar"
+	pkg.Version = ""
+	pkg.Stages.Install = "if true; then\n"
+	ps, ok := pkg.LintRules("invalid-name", "unknown-rule").(Problems)
+	if !ok || len(ps) != 1 || ps[0].Rule != "invalid-name" {
+		t.Fatalf("expected a single '%s', found: %v", "invalid-name", ps)
+	}
+	if err := pkg.LintRules(); err != nil {
+		t.Errorf("Expected no error, found: %s", err)
+	}
+}
+
+func TestLintMacros(t *testing.T) {
+	pkg := lintable(t)
+	pkg.Stages.Setup = "%confgure --disable-static\n"
+	pkg.Stages.Install = "%make_install\nrm %installroot%/%libdri%/*.a\n"
+	ps, ok := pkg.Lint().(Problems)
+	if !ok || len(ps) != 2 {
+		t.Fatalf("expected 2 problems, found: %v", ps)
+	}
+	if p := ps[0]; p.Rule != "unknown-macro" || p.Field != "setup" || p.Line != 1 {
+		t.Errorf("expected '%s' on '%s:%d', found: %s", "unknown-macro", "setup", 1, p)
+	}
+	if p := ps[1]; p.Field != "install" || p.Line != 2 || !strings.Contains(p.Message, "%libdri%") {
+		t.Errorf("expected '%s' on '%s:%d', found: %s", "%libdri%", "install", 2, p)
+	}
+}
+
+func TestLintMacrosKnown(t *testing.T) {
+	pkg := lintable(t)
+	pkg.Stages.Setup = "%apply_patches\n%qmake4\n%go_fetch\n%cargo_fetch\n"
+	pkg.Stages.Install = "%make_install\n%python3_compile\n%python_compile\n%install_license COPYING\n" +
+		"install -Dm00644 foo.pc \"$installdir/usr/lib%libsuffix%/pkgconfig/foo.pc\"\n"
+	if err := pkg.Lint(); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+}
+
+func TestLintScripts(t *testing.T) {
+	pkg := lintable(t)
+	pkg.Stages.Setup = "if true; then\n    %configure\nfi fi\n"
+	pkg.Stages.Install = "%make_install\ncd build\nrm -rf $installdir/usr/share/doc\ninstall -Dm00755 foo /usr/lib64/foo\nmake install\n"
+	ps, ok := pkg.Lint().(Problems)
+	if !ok {
+		t.Fatalf("Expected Problems, found: %v", ps)
+	}
+	expected := []struct {
+		rule, field string
+		line        int
+	}{
+		{"invalid-script", "setup", 3},
+		{"prefer-macro", "install", 5},
+		{"cd-without-workdir", "install", 2},
+		{"unquoted-rm", "install", 3},
+		{"hardcoded-libdir", "install", 4},
+	}
+	if len(ps) != len(expected) {
+		t.Fatalf("expected %d problems, found: %s", len(expected), ps)
+	}
+	for i, e := range expected {
+		if p := ps[i]; p.Rule != e.rule || p.Field != e.field || p.Line != e.line {
+			t.Errorf("expected '%s' on '%s:%d', found: %s", e.rule, e.field, e.line, p)
+		}
+	}
+}
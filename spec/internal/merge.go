@@ -0,0 +1,324 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"dev.getsol.us/source/libypkg.git/spec/shared/array"
+	"dev.getsol.us/source/libypkg.git/spec/shared/diff"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strings"
+)
+
+// Conflict is a field which was changed differently on both sides of a merge
+type Conflict struct {
+	// Field is the key in conflict, with nested keys separated by dots like "flags.clang"
+	Field string
+	// Package is the subpackage in conflict, or empty for the main package
+	Package string
+}
+
+// String names the field in conflict
+func (c Conflict) String() string {
+	if len(c.Package) > 0 {
+		return c.Field + " (" + c.Package + ")"
+	}
+	return c.Field
+}
+
+// Conflicts are every field which could not be merged automatically
+type Conflicts []Conflict
+
+// Error lists every Conflict
+func (cs Conflicts) Error() string {
+	var fields []string
+	for _, c := range cs {
+		fields = append(fields, c.String())
+	}
+	return fmt.Sprintf("merge conflicts in: %s", strings.Join(fields, ", "))
+}
+
+// merger combines three versions of a package, collecting any Conflicts
+type merger struct {
+	conflicts Conflicts
+}
+
+// conflict records a field that could not be merged
+func (m *merger) conflict(field, pkg string) {
+	m.conflicts = append(m.conflicts, Conflict{Field: field, Package: pkg})
+}
+
+// scalar merges a single value, keeping "ours" on a conflict
+func (m *merger) scalar(field, pkg, base, ours, theirs string) string {
+	switch {
+	case ours == theirs, theirs == base:
+		return ours
+	case ours == base:
+		return theirs
+	}
+	m.conflict(field, pkg)
+	return ours
+}
+
+// script merges a multi-line script line by line, leaving conflict markers where both sides changed the same lines
+func (m *merger) script(field, base, ours, theirs string) string {
+	switch {
+	case ours == theirs, theirs == base:
+		return ours
+	case ours == base:
+		return theirs
+	}
+	out, conflict := diff.MergeText(base, ours, theirs)
+	if conflict {
+		m.conflict(field, "")
+	}
+	return out
+}
+
+// mergeSet merges lists of nodes as sets
+//
+// The order of "ours" is kept, followed by anything added by "theirs". Anything
+// removed by either side is left out.
+func mergeSet(base, ours, theirs []*yaml.Node) (out []*yaml.Node) {
+	in := func(nodes []*yaml.Node) map[string]bool {
+		set := make(map[string]bool)
+		for _, node := range nodes {
+			set[node.Value] = true
+		}
+		return set
+	}
+	b, o, t := in(base), in(ours), in(theirs)
+	seen := make(map[string]bool)
+	for _, node := range ours {
+		if (!b[node.Value] || t[node.Value]) && !seen[node.Value] {
+			seen[node.Value] = true
+			out = append(out, node)
+		}
+	}
+	for _, node := range theirs {
+		if !b[node.Value] && !o[node.Value] && !seen[node.Value] {
+			seen[node.Value] = true
+			out = append(out, node)
+		}
+	}
+	return
+}
+
+// pointers gets a pointer to each node in a list
+func pointers(nodes []yaml.Node) (ptrs []*yaml.Node) {
+	for i := range nodes {
+		ptrs = append(ptrs, &nodes[i])
+	}
+	return
+}
+
+// nodeList merges lists of nodes as sets, like mergeSet
+func nodeList(base, ours, theirs []yaml.Node) (out []yaml.Node) {
+	for _, node := range mergeSet(pointers(base), pointers(ours), pointers(theirs)) {
+		out = append(out, *node)
+	}
+	return
+}
+
+// stringList merges lists of strings as sets, like mergeSet
+func stringList(base, ours, theirs []string) (out []string) {
+	nodes := func(vs []string) (ns []*yaml.Node) {
+		for _, v := range vs {
+			ns = append(ns, &yaml.Node{Kind: yaml.ScalarNode, Value: v})
+		}
+		return
+	}
+	for _, node := range mergeSet(nodes(base), nodes(ours), nodes(theirs)) {
+		out = append(out, node.Value)
+	}
+	return
+}
+
+// listMap merges each subpackage of a ListMap as a set
+func listMap(base, ours, theirs array.ListMap) array.ListMap {
+	out := array.NewListMap()
+	for _, name := range names(ours, theirs) {
+		if merged := mergeSet(base[name], ours[name], theirs[name]); len(merged) > 0 {
+			out[name] = merged
+		}
+	}
+	return out
+}
+
+// namedMap merges each subpackage of a Map as a single value
+func (m *merger) namedMap(field string, base, ours, theirs array.Map) array.Map {
+	get := func(am array.Map, name string) string {
+		if node, ok := am[name]; ok && node != nil {
+			return node.Value
+		}
+		return ""
+	}
+	out := array.NewMap()
+	for _, name := range mapNames(base, ours, theirs) {
+		value := m.scalar(field, subpackage(name), get(base, name), get(ours, name), get(theirs, name))
+		if len(value) == 0 {
+			continue
+		}
+		for _, side := range []array.Map{ours, theirs, base} {
+			if node, ok := side[name]; ok && node != nil && node.Value == value {
+				out[name] = node
+				break
+			}
+		}
+	}
+	return out
+}
+
+// sources merges the sources as a set, by URI
+//
+// A source whose hash or reference was changed on both sides is a conflict, and so is a source
+// changed on one side and removed on the other, which keeps the changed source.
+func (m *merger) sources(base, ours, theirs []shared.SourceURI) (out []shared.SourceURI) {
+	index := func(srcs []shared.SourceURI) map[string]shared.SourceURI {
+		found := make(map[string]shared.SourceURI)
+		for _, src := range srcs {
+			found[src.Key()] = src
+		}
+		return found
+	}
+	field := func(src shared.SourceURI) string {
+		return "source[" + src.Key() + "]"
+	}
+	b, o, t := index(base), index(ours), index(theirs)
+	for _, src := range ours {
+		prev, inBase := b[src.Key()]
+		other, inTheirs := t[src.Key()]
+		switch {
+		case !inBase:
+			out = append(out, src)
+		case !inTheirs:
+			// Removed by theirs, unless ours changed it
+			if src.Value() != prev.Value() {
+				m.conflict(field(src), "")
+				out = append(out, src)
+			}
+		case m.scalar(field(src), "", prev.Value(), src.Value(), other.Value()) == src.Value():
+			out = append(out, src)
+		default:
+			out = append(out, other)
+		}
+	}
+	for _, src := range theirs {
+		prev, inBase := b[src.Key()]
+		if _, inOurs := o[src.Key()]; inOurs {
+			continue
+		}
+		switch {
+		case !inBase:
+			out = append(out, src)
+		case src.Value() != prev.Value():
+			// Removed by ours, but changed by theirs
+			m.conflict(field(src), "")
+			out = append(out, src)
+		}
+	}
+	return
+}
+
+// valuer is a flag whose effective value may differ from how it is written, like shared.DefaultTrue
+type valuer interface {
+	Value() bool
+}
+
+// effective gets the value of a flag as ypkg sees it, so that an unset flag matches its default
+func effective(v reflect.Value) interface{} {
+	if flag, ok := v.Interface().(valuer); ok {
+		return flag.Value()
+	}
+	return v.Interface()
+}
+
+// flags merges each flag separately by its effective value, and the optimize values as a set
+func (m *merger) flags(base, ours, theirs BuildFlags) (out BuildFlags) {
+	b, o, t := reflect.ValueOf(base), reflect.ValueOf(ours), reflect.ValueOf(theirs)
+	result := reflect.ValueOf(&out).Elem()
+	for i := 0; i < b.NumField(); i++ {
+		name := "flags." + strings.Split(b.Type().Field(i).Tag.Get("yaml"), ",")[0]
+		if optimize, ok := b.Field(i).Interface().([]string); ok {
+			result.Field(i).Set(reflect.ValueOf(stringList(optimize, o.Field(i).Interface().([]string), t.Field(i).Interface().([]string))))
+			continue
+		}
+		bv, ov, tv := effective(b.Field(i)), effective(o.Field(i)), effective(t.Field(i))
+		switch {
+		case ov == tv, tv == bv:
+			result.Field(i).Set(o.Field(i))
+		case ov == bv:
+			result.Field(i).Set(t.Field(i))
+		default:
+			m.conflict(name, "")
+			result.Field(i).Set(o.Field(i))
+		}
+	}
+	return
+}
+
+// Merge combines the changes made to a common "base" package by "ours" and "theirs"
+//
+// Lists like sources, dependencies and patterns are merged as sets. If both sides
+// increased the release, the result gets a release above both of them. Stage
+// scripts are merged line by line. Anything else changed differently on both sides
+// keeps the value from "ours" and is reported as a Conflict.
+func Merge(base, ours, theirs *PackageYML) (merged *PackageYML, conflicts Conflicts) {
+	m := &merger{}
+	merged = NewPackage()
+	merged.YPKG = ours.YPKG
+	merged.Name = m.scalar("name", "", base.Name, ours.Name, theirs.Name)
+	merged.Version = m.scalar("version", "", base.Version, ours.Version, theirs.Version)
+	merged.Release = ours.Release
+	if theirs.Release > merged.Release {
+		merged.Release = theirs.Release
+	}
+	if ours.Release > base.Release && theirs.Release > base.Release {
+		merged.Release++
+	}
+	merged.Source = m.sources(base.Source, ours.Source, theirs.Source)
+	merged.Homepage = m.scalar("homepage", "", base.Homepage, ours.Homepage, theirs.Homepage)
+	merged.License = nodeList(base.License, ours.License, theirs.License)
+	merged.Component = m.scalar("component", "", base.Component, ours.Component, theirs.Component)
+	merged.Components = m.namedMap("components", base.Components, ours.Components, theirs.Components)
+	merged.Summary = m.scalar("summary", "", base.Summary, ours.Summary, theirs.Summary)
+	merged.Summaries = m.namedMap("summaries", base.Summaries, ours.Summaries, theirs.Summaries)
+	merged.Description = m.scalar("description", "", base.Description, ours.Description, theirs.Description)
+	merged.Descriptions = m.namedMap("descriptions", base.Descriptions, ours.Descriptions, theirs.Descriptions)
+	merged.Dependencies = PackageDeps{
+		Replaces:  listMap(base.Dependencies.Replaces, ours.Dependencies.Replaces, theirs.Dependencies.Replaces),
+		Conflicts: listMap(base.Dependencies.Conflicts, ours.Dependencies.Conflicts, theirs.Dependencies.Conflicts),
+		Build:     nodeList(base.Dependencies.Build, ours.Dependencies.Build, theirs.Dependencies.Build),
+		Check:     nodeList(base.Dependencies.Check, ours.Dependencies.Check, theirs.Dependencies.Check),
+		Run:       listMap(base.Dependencies.Run, ours.Dependencies.Run, theirs.Dependencies.Run),
+	}
+	merged.Flags = m.flags(base.Flags, ours.Flags, theirs.Flags)
+	merged.Environment = m.script("environment", base.Environment, ours.Environment, theirs.Environment)
+	merged.Stages = BuildStages{
+		Setup:   m.script("setup", base.Stages.Setup, ours.Stages.Setup, theirs.Stages.Setup),
+		Build:   m.script("build", base.Stages.Build, ours.Stages.Build, theirs.Stages.Build),
+		Profile: m.script("profile", base.Stages.Profile, ours.Stages.Profile, theirs.Stages.Profile),
+		Check:   m.script("check", base.Stages.Check, ours.Stages.Check, theirs.Stages.Check),
+		Install: m.script("install", base.Stages.Install, ours.Stages.Install, theirs.Stages.Install),
+	}
+	merged.Permanent = listMap(base.Permanent, ours.Permanent, theirs.Permanent)
+	merged.Patterns = listMap(base.Patterns, ours.Patterns, theirs.Patterns)
+	conflicts = m.conflicts
+	return
+}
@@ -17,33 +17,33 @@
 package internal
 
 import (
+	"context"
 	"dev.getsol.us/source/libypkg.git/spec/shared"
 	"dev.getsol.us/source/libypkg.git/spec/shared/array"
-	"errors"
 	"gopkg.in/yaml.v3"
 )
 
 // PackageYML is the v3 representation of the Package YML specification
 type PackageYML struct {
-	YPKG         int             `yaml:"YPKG"`
-	Name         string          `yaml:"name"`
-	Version      string          `yaml:"version"`
-	Release      uint            `yaml:"release"`
-	Source       []shared.Source `yaml:"source"`
-	Homepage     string          `yaml:"homepage,omitempty"`
-	License      shared.Licenses `yaml:"license"`
-	Component    string          `yaml:"component"`
-	Components   array.Map       `yaml:"components"`
-	Summary      string          `yaml:"summary"`
-	Summaries    array.Map       `yaml:"summaries"`
-	Description  string          `yaml:"description"`
-	Descriptions array.Map       `yaml:"descriptions"`
-	Dependencies PackageDeps     `yaml:"deps,omitempty"`
-	Flags        BuildFlags      `yaml:"flags,omitempty"`
-	Environment  string          `yaml:"environment,omitempty"`
-	Stages       BuildStages     `yaml:",inline"`
-	Permanent    array.ListMap   `yaml:"permanent,omitempty"`
-	Patterns     array.ListMap   `yaml:"patterns,omitempty"`
+	YPKG         int                `yaml:"YPKG"`
+	Name         string             `yaml:"name"`
+	Version      string             `yaml:"version"`
+	Release      uint               `yaml:"release"`
+	Source       []shared.SourceURI `yaml:"source"`
+	Homepage     string             `yaml:"homepage,omitempty"`
+	License      shared.Licenses    `yaml:"license"`
+	Component    string             `yaml:"component"`
+	Components   array.Map          `yaml:"components"`
+	Summary      string             `yaml:"summary"`
+	Summaries    array.Map          `yaml:"summaries"`
+	Description  string             `yaml:"description"`
+	Descriptions array.Map          `yaml:"descriptions"`
+	Dependencies PackageDeps        `yaml:"deps,omitempty"`
+	Flags        BuildFlags         `yaml:"flags,omitempty"`
+	Environment  string             `yaml:"environment,omitempty"`
+	Stages       BuildStages        `yaml:",inline"`
+	Permanent    array.ListMap      `yaml:"permanent,omitempty"`
+	Patterns     array.ListMap      `yaml:"patterns,omitempty"`
 }
 
 // NewPackage returns an empty package
@@ -61,12 +61,13 @@ func NewPackage() *PackageYML {
 // Default provides a skeleton for a new package
 func Default() *PackageYML {
 	return &PackageYML{
-		Name:    "Name-Of-Package",
-		Version: "1.0.0a",
+		Name:    placeholderName,
+		Version: placeholderVersion,
 		Release: 1,
-		Source: []shared.Source{
-			shared.Source{
-				"URI": "HASH",
+		Source: []shared.SourceURI{
+			shared.SourceURI{
+				URL:    placeholderURI,
+				Digest: shared.ParseDigest(placeholderHash),
 			},
 		},
 		License: shared.Licenses{
@@ -75,11 +76,11 @@ func Default() *PackageYML {
 				Value: "GPL-2.0-or-later # CHECK AND/OR CHANGE ME",
 			},
 		},
-		Component:   "# SET ME",
-		Summary:     "# Short description of the package",
-		Description: "# Long description of the package\n# Can be multiple lines\n# Just no 80/100 column wrapping!\n",
+		Component:   placeholderComponent,
+		Summary:     placeholderSummary,
+		Description: placeholderDescription,
 		Stages: BuildStages{
-			Install: "# do some things\n# and stuff\n",
+			Install: placeholderInstall,
 		},
 	}
 }
@@ -89,24 +90,40 @@ func (pkg *PackageYML) Bump() {
 	pkg.Release++
 }
 
-// Update replaces the existing source with newer ones
-func (pkg *PackageYML) Update(version string, sources []string) error {
+// Update replaces the existing source with newer ones, fetched with "f" or the DefaultFetcher if nil
+//
+// If no sources are provided, the existing ones are rewritten for the new version and hashed again
+func (pkg *PackageYML) Update(version string, sources []string, f *shared.Fetcher) (err error) {
+	if err = pkg.checkDowngrade(version); err != nil {
+		return
+	}
+	if len(sources) == 0 {
+		if sources, err = pkg.nextSources(version); err != nil {
+			return
+		}
+	}
+	if f == nil {
+		f = shared.DefaultFetcher
+	}
+	srcs, err := f.UpdateSources(context.Background(), sources, 1, nil)
+	if err != nil {
+		return
+	}
 	pkg.Version = version
-	return errors.New("Not yet implemented")
-}
-
-// Lint checks over the package for any obvious errors or questionable choices
-func (pkg *PackageYML) Lint() error {
-	return errors.New("Not yet implemented")
+	pkg.Source = srcs
+	return
 }
 
 // Auto creates a new package from a list of sources
-func Auto(sources []string) (pkg *PackageYML, err error) {
+//
+// Fields which cannot be detected from the first source keep their placeholders from Default. The
+// sources are fetched with "f", or the DefaultFetcher if nil.
+func Auto(sources []string, f *shared.Fetcher) (pkg *PackageYML, err error) {
 	pkg = Default()
-	if err = pkg.Update(pkg.Version, sources); err != nil {
-		return
-	}
 	// Inspect the first source to fill out as many fields as possible
-	err = errors.New("Not yet implemented")
+	if len(sources) > 0 {
+		pkg.Detect(sources[0])
+	}
+	err = pkg.Update(pkg.Version, sources, f)
 	return
 }
@@ -0,0 +1,138 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared/array"
+	"dev.getsol.us/source/libypkg.git/spec/shared/constant"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The sentinel values written by Default, which must all be replaced before a package is finished
+const (
+	placeholderName        = "Name-Of-Package"
+	placeholderVersion     = "1.0.0a"
+	placeholderURI         = "URI"
+	placeholderHash        = "HASH"
+	placeholderLicense     = "CHECK AND/OR CHANGE ME"
+	placeholderComponent   = "# SET ME"
+	placeholderSummary     = "# Short description of the package"
+	placeholderDescription = "# Long description of the package\n# Can be multiple lines\n# Just no 80/100 column wrapping!\n"
+	placeholderInstall     = "# do some things\n# and stuff\n"
+)
+
+// placeholder describes a value which was left over from the template
+func placeholder(field string, line int, value string) Problem {
+	return Problem{
+		Rule:    "template-placeholder",
+		Field:   field,
+		Line:    line,
+		Message: fmt.Sprintf("'%s' is a placeholder from the template and must be replaced", strings.TrimSpace(value)),
+	}
+}
+
+// placeholderMap finds the sub-packages of a map which still have a placeholder value
+func placeholderMap(field string, m array.Map, sentinel string) (ps Problems) {
+	for _, name := range mapNames(m) {
+		if node := m[name]; node != nil && strings.HasPrefix(node.Value, sentinel) {
+			ps = append(ps, placeholder(field+"."+name, 0, sentinel))
+		}
+	}
+	return
+}
+
+// placeholderLines finds every line of a value which is still one of the lines of a multi-line placeholder
+func placeholderLines(field, value, template string) (ps Problems) {
+	sentinels := make(map[string]bool)
+	for _, line := range strings.Split(template, "\n") {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			sentinels[line] = true
+		}
+	}
+	for i, line := range strings.Split(value, "\n") {
+		if sentinels[strings.TrimSpace(line)] {
+			ps = append(ps, placeholder(field, i+1, line))
+		}
+	}
+	return
+}
+
+// mapNames gets the sub-packages in any of the maps, with the main package first and the rest sorted by name
+func mapNames(ms ...array.Map) (names []string) {
+	seen := make(map[string]bool)
+	var main bool
+	for _, m := range ms {
+		for name := range m {
+			if name == constant.DefaultPackage {
+				main = true
+			} else if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	if main {
+		names = append([]string{constant.DefaultPackage}, names...)
+	}
+	return
+}
+
+// Placeholders finds every value written by Default which has not been replaced yet
+func (pkg *PackageYML) Placeholders() (ps Problems) {
+	if pkg.Name == placeholderName {
+		ps = append(ps, placeholder("name", 0, pkg.Name))
+	}
+	if pkg.Version == placeholderVersion {
+		ps = append(ps, placeholder("version", 0, pkg.Version))
+	}
+	for i, src := range pkg.Source {
+		if src.URL == placeholderURI {
+			ps = append(ps, placeholder("source", i+1, src.URL))
+		} else if !src.IsGit() && src.Digest.Value == placeholderHash {
+			ps = append(ps, placeholder("source", i+1, src.Digest.Value))
+		}
+	}
+	for i, license := range pkg.License {
+		if strings.Contains(license.Value, placeholderLicense) || strings.Contains(license.LineComment, placeholderLicense) {
+			ps = append(ps, placeholder("license", i+1, placeholderLicense))
+		}
+	}
+	if strings.HasPrefix(pkg.Component, placeholderComponent) {
+		ps = append(ps, placeholder("component", 0, pkg.Component))
+	}
+	ps = append(ps, placeholderMap("components", pkg.Components, placeholderComponent)...)
+	if strings.HasPrefix(pkg.Summary, placeholderSummary) {
+		ps = append(ps, placeholder("summary", 0, pkg.Summary))
+	}
+	ps = append(ps, placeholderMap("summaries", pkg.Summaries, placeholderSummary)...)
+	ps = append(ps, placeholderLines("description", pkg.Description, placeholderDescription)...)
+	for _, name := range mapNames(pkg.Descriptions) {
+		if node := pkg.Descriptions[name]; node != nil {
+			ps = append(ps, placeholderLines("descriptions."+name, node.Value, placeholderDescription)...)
+		}
+	}
+	ps = append(ps, placeholderLines("install", pkg.Stages.Install, placeholderInstall)...)
+	return
+}
+
+// checkPlaceholders makes sure that nothing from the template survives in a package
+func checkPlaceholders(pkg *PackageYML) Problems {
+	return pkg.Placeholders()
+}
@@ -0,0 +1,67 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPlaceholders(t *testing.T) {
+	ps := Default().Placeholders()
+	expected := []string{"name", "version", "source:1", "license:1", "component", "summary", "description:1", "description:2", "description:3", "install:1", "install:2"}
+	if len(ps) != len(expected) {
+		t.Fatalf("expected %d placeholders, found: %v", len(expected), ps)
+	}
+	for i, p := range ps {
+		if p.Severity != Error {
+			t.Errorf("expected '%s', found: %s", Error, p.Severity)
+		}
+		field := p.Field
+		if p.Line > 0 {
+			field = fmt.Sprintf("%s:%d", field, p.Line)
+		}
+		if field != expected[i] {
+			t.Errorf("expected '%s', found: %s", expected[i], p)
+		}
+	}
+	if ps := lintable(t).Placeholders(); len(ps) != 0 {
+		t.Errorf("expected no placeholders, found: %v", ps)
+	}
+}
+
+func TestPlaceholdersPartial(t *testing.T) {
+	pkg := Default()
+	pkg.Name = "foo"
+	pkg.Version = "1.0"
+	pkg.Source = lintable(t).Source
+	pkg.Description = "Foo does things\n# Can be multiple lines\n"
+	pkg.Stages.Install = "%make_install\n    # and stuff\n"
+	ps := pkg.Placeholders()
+	if len(ps) != 5 {
+		t.Fatalf("expected %d placeholders, found: %v", 5, ps)
+	}
+	if p := ps[3]; p.Field != "description" || p.Line != 2 {
+		t.Errorf("expected '%s', found: %s", "description:2", p)
+	}
+	if p := ps[4]; p.Field != "install" || p.Line != 2 {
+		t.Errorf("expected '%s', found: %s", "install:2", p)
+	}
+	if p := ps[1]; p.Message != "'# SET ME' is a placeholder from the template and must be replaced" {
+		t.Errorf("expected '%s', found: %s", "# SET ME", p.Message)
+	}
+}
@@ -0,0 +1,135 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// PGO is the part a Pass plays in a profile guided build
+type PGO int
+
+const (
+	// NoPGO is a Pass which does not use a profile
+	NoPGO PGO = iota
+	// Generate is a Pass which builds instrumented binaries and runs the profile stage to create a profile
+	Generate
+	// Use is a Pass which builds with the profile created by Generate
+	Use
+)
+
+// Pass is a single run through the build stages of a package
+type Pass struct {
+	// Name identifies the Pass, like "emul32" or "native-pgo-generate"
+	Name string
+	// Arch is the kind of binaries being built
+	Arch Arch
+	// PGO is the part this Pass plays in a profile guided build
+	PGO PGO
+	// Stages are the names of the build stages which are run, in order
+	Stages []string
+	// Environment are the extra variables set for every stage, as "KEY=value"
+	Environment []string
+	// Toolchain is the compiler and flags used
+	Toolchain Toolchain
+}
+
+// String describes this Pass in a single line
+func (p Pass) String() string {
+	return fmt.Sprintf("%s: %s [%s]", p.Name, strings.Join(p.Stages, ", "), strings.Join(p.Environment, " "))
+}
+
+// stages gets the names of the stages which are set, from a list in the order they run
+func (stages BuildStages) stages(names ...string) (set []string) {
+	for _, name := range names {
+		if script, _ := stages.Stage(name); len(strings.TrimSpace(script)) > 0 {
+			set = append(set, name)
+		}
+	}
+	return
+}
+
+// profile adds the flags for one side of a profile guided build to a Toolchain
+func (t *Toolchain) profile(pgo PGO, dir string) {
+	switch {
+	case pgo == Generate && t.Clang:
+		t.compile("-fprofile-generate=" + dir)
+		t.LDFLAGS = append(t.LDFLAGS, "-fprofile-generate="+dir)
+	case pgo == Generate:
+		t.compile("-fprofile-generate", "-fprofile-dir="+dir)
+		t.LDFLAGS = append(t.LDFLAGS, "-fprofile-generate")
+	case pgo == Use && t.Clang:
+		t.compile("-fprofile-instr-use=" + filepath.Join(dir, "default.profdata"))
+	case pgo == Use:
+		t.compile("-fprofile-use", "-fprofile-dir="+dir, "-fprofile-correction")
+	}
+}
+
+// Plan finds every Pass ypkg makes through the build stages, in the order they are run
+//
+// The 32-bit and AVX2 passes are run before the native pass. When there is a profile stage, each
+// Arch is built twice, first to generate a profile in a directory under "pgoDir", and then to use it.
+func (pkg *PackageYML) Plan(pgoDir string) (passes []Pass, err error) {
+	var arches []Arch
+	if pkg.Flags.Emul32.Value() {
+		arches = append(arches, Emul32)
+	}
+	if pkg.Flags.AVX2.Value() {
+		arches = append(arches, AVX2)
+	}
+	arches = append(arches, Native)
+	profile := len(strings.TrimSpace(pkg.Stages.Profile)) > 0
+	for _, arch := range arches {
+		var env []string
+		switch arch {
+		case Emul32:
+			env = append(env, "EMUL32BUILD=1")
+		case AVX2:
+			env = append(env, "AVX2BUILD=1")
+		}
+		pgos := []PGO{NoPGO}
+		if profile {
+			pgos = []PGO{Generate, Use}
+		}
+		for _, pgo := range pgos {
+			p := Pass{
+				Name:        arch.String(),
+				Arch:        arch,
+				PGO:         pgo,
+				Environment: env,
+			}
+			if p.Toolchain, err = pkg.Flags.Resolve(arch); err != nil {
+				return
+			}
+			switch pgo {
+			case Generate:
+				p.Name += "-pgo-generate"
+				p.Stages = pkg.Stages.stages("setup", "build", "profile")
+			case Use:
+				p.Name += "-pgo-use"
+				fallthrough
+			default:
+				p.Stages = pkg.Stages.stages("setup", "build", "install", "check")
+			}
+			p.Toolchain.profile(pgo, filepath.Join(pgoDir, arch.String()))
+			passes = append(passes, p)
+		}
+	}
+	return
+}
@@ -0,0 +1,75 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"strings"
+	"testing"
+)
+
+func TestPlan(t *testing.T) {
+	pkg := lintable(t)
+	pkg.Stages.Setup = "%configure"
+	passes, err := pkg.Plan("/pgo")
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if len(passes) != 1 {
+		t.Fatalf("expected a single pass, found: %v", passes)
+	}
+	if result := passes[0].String(); result != "native: setup, install []" {
+		t.Errorf("expected '%s', found: %s", "native: setup, install []", result)
+	}
+}
+
+func TestPlanMatrix(t *testing.T) {
+	pkg := lintable(t)
+	pkg.Flags.Emul32 = shared.DefaultFalse{Valid: true, Bool: true}
+	pkg.Flags.AVX2 = shared.DefaultFalse{Valid: true, Bool: true}
+	pkg.Stages.Build = "%make"
+	pkg.Stages.Profile = "%make check"
+	pkg.Stages.Check = "%make check"
+	passes, err := pkg.Plan("/pgo")
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	expected := []string{
+		"emul32-pgo-generate: build, profile [EMUL32BUILD=1]",
+		"emul32-pgo-use: build, install, check [EMUL32BUILD=1]",
+		"avx2-pgo-generate: build, profile [AVX2BUILD=1]",
+		"avx2-pgo-use: build, install, check [AVX2BUILD=1]",
+		"native-pgo-generate: build, profile []",
+		"native-pgo-use: build, install, check []",
+	}
+	if len(passes) != len(expected) {
+		t.Fatalf("expected %d passes, found: %v", len(expected), passes)
+	}
+	for i, p := range passes {
+		if result := p.String(); result != expected[i] {
+			t.Errorf("expected '%s', found: %s", expected[i], result)
+		}
+	}
+	generate := strings.Join(passes[0].Toolchain.CFLAGS, " ")
+	if !strings.Contains(generate, "-march=i686") || !strings.Contains(generate, "-fprofile-generate=/pgo/emul32") {
+		t.Errorf("expected 32-bit profile generation flags, found: %s", generate)
+	}
+	use := strings.Join(passes[5].Toolchain.CFLAGS, " ")
+	if !strings.Contains(use, "-fprofile-instr-use=/pgo/native/default.profdata") {
+		t.Errorf("expected profile use flags, found: %s", use)
+	}
+}
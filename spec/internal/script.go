@@ -0,0 +1,201 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared/macro"
+	"dev.getsol.us/source/libypkg.git/spec/shared/shell"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownStage indicates a build stage which does not exist
+var ErrUnknownStage = errors.New("unknown build stage")
+
+// Macros is the Catalog used to check and expand build stages, which may be replaced by one loaded from rc.yml
+var Macros = macro.Default()
+
+// Script builds the final shell script for a stage of the package, for a single pass of the build
+//
+// The environment of the package is run before the stage, and every macro is expanded for the Context.
+func (pkg *PackageYML) Script(stage string, ctx macro.Context) (string, error) {
+	script, ok := pkg.Stages.Stage(stage)
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownStage, stage)
+	}
+	ctx.Name = pkg.Name
+	ctx.Version = pkg.Version
+	ctx.Release = pkg.Release
+	return Macros.Script(pkg.Environment, script, ctx)
+}
+
+// scripts gets the environment and every build stage which is set, by field
+func (pkg *PackageYML) scripts() (fields, scripts []string) {
+	for _, name := range append([]string{"environment"}, StageNames...) {
+		script := pkg.Environment
+		if name != "environment" {
+			script, _ = pkg.Stages.Stage(name)
+		}
+		if len(strings.TrimSpace(script)) > 0 {
+			fields = append(fields, name)
+			scripts = append(scripts, script)
+		}
+	}
+	return
+}
+
+// checkCommands runs a check over every Command of the environment and build stages
+func checkCommands(pkg *PackageYML, check func(field string, cmd shell.Command) Problems) (ps Problems) {
+	fields, scripts := pkg.scripts()
+	for i, script := range scripts {
+		for _, cmd := range shell.Parse(script) {
+			ps = append(ps, check(fields[i], cmd)...)
+		}
+	}
+	return
+}
+
+// checkMacros makes sure that the environment and every build stage only use known macros
+func checkMacros(pkg *PackageYML) (ps Problems) {
+	fields, scripts := pkg.scripts()
+	for i, script := range scripts {
+		for _, u := range Macros.Unknown(script) {
+			ps = append(ps, Problem{Field: fields[i], Line: u.Line, Message: fmt.Sprintf("unknown macro '%s'", u.Name)})
+		}
+	}
+	return
+}
+
+// checkSyntax makes sure that the environment and every build stage can be parsed by bash, once expanded
+func checkSyntax(pkg *PackageYML) (ps Problems) {
+	fields, scripts := pkg.scripts()
+	for i, script := range scripts {
+		out, lines, err := Macros.ExpandLines(script, macro.Context{})
+		if err != nil {
+			continue
+		}
+		serr, ok := shell.CheckSyntax(out).(*shell.SyntaxError)
+		if !ok {
+			continue
+		}
+		line := len(lines)
+		if serr.Line <= len(lines) {
+			line = lines[serr.Line-1]
+		}
+		ps = append(ps, Problem{Field: fields[i], Line: line, Message: serr.Message})
+	}
+	return
+}
+
+// rawCommands are commands which should be replaced by a macro, by the name and arguments which identify them
+var rawCommands = []struct {
+	args  []string
+	macro string
+}{
+	{[]string{"./configure"}, "%configure"},
+	{[]string{"autoreconf"}, "%reconfigure"},
+	{[]string{"make", "install"}, "%make_install"},
+	{[]string{"ninja", "install"}, "%ninja_install"},
+	{[]string{"patch"}, "%patch"},
+}
+
+// matches checks if the arguments of a Command start with a name and include every other argument
+func matches(args, pattern []string) bool {
+	if len(args) == 0 || args[0] != pattern[0] {
+		return false
+	}
+	for _, want := range pattern[1:] {
+		found := false
+		for _, arg := range args[1:] {
+			found = found || arg == want
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRawCommands finds commands which have a macro that sets the standard options for them
+func checkRawCommands(pkg *PackageYML) Problems {
+	return checkCommands(pkg, func(field string, cmd shell.Command) (ps Problems) {
+		args := cmd.Args()
+		for _, raw := range rawCommands {
+			if matches(args, raw.args) {
+				msg := fmt.Sprintf("use '%s' instead of '%s'", raw.macro, strings.Join(raw.args, " "))
+				ps = append(ps, Problem{Field: field, Line: cmd.Line, Severity: Warning, Message: msg})
+				break
+			}
+		}
+		return
+	})
+}
+
+// checkCd finds changes to relative directories, which depend on where the previous command left off
+func checkCd(pkg *PackageYML) Problems {
+	return checkCommands(pkg, func(field string, cmd shell.Command) (ps Problems) {
+		args := cmd.Args()
+		if len(args) < 2 || args[0] != "cd" {
+			return
+		}
+		dir := strings.Trim(args[1], `"'`)
+		if strings.Contains(dir, "workdir") || dir == "-" || strings.HasPrefix(dir, "/") || strings.HasPrefix(dir, "$") || strings.HasPrefix(dir, "%") {
+			return
+		}
+		msg := fmt.Sprintf("'cd %s' is relative to the current directory, use 'cd %%workdir%%/%s'", dir, dir)
+		ps = append(ps, Problem{Field: field, Line: cmd.Line, Severity: Warning, Message: msg})
+		return
+	})
+}
+
+// checkRm finds recursive or forced removals of unquoted variables, which remove the wrong files if they are empty or split
+func checkRm(pkg *PackageYML) Problems {
+	return checkCommands(pkg, func(field string, cmd shell.Command) (ps Problems) {
+		args := cmd.Args()
+		if len(args) == 0 || args[0] != "rm" {
+			return
+		}
+		force := false
+		for _, arg := range args[1:] {
+			force = force || strings.HasPrefix(arg, "-") && strings.ContainsAny(arg, "rRf")
+		}
+		if !force {
+			return
+		}
+		for _, w := range cmd.Words {
+			if !w.Quoted && strings.Contains(w.Text, "$") && !w.IsAssignment() {
+				msg := fmt.Sprintf("quote '%s' when removing it with '%s'", w.Text, strings.Join(args[:2], " "))
+				ps = append(ps, Problem{Field: field, Line: cmd.Line, Severity: Warning, Message: msg})
+			}
+		}
+		return
+	})
+}
+
+// checkLibdir finds hard-coded library directories, which are wrong for emul32 and AVX2 builds
+func checkLibdir(pkg *PackageYML) Problems {
+	return checkCommands(pkg, func(field string, cmd shell.Command) (ps Problems) {
+		for _, w := range cmd.Words {
+			if strings.Contains(w.Text, "/usr/lib64") {
+				ps = append(ps, Problem{Field: field, Line: cmd.Line, Severity: Warning, Message: "use '%libdir%' instead of '/usr/lib64'"})
+				break
+			}
+		}
+		return
+	})
+}
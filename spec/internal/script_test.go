@@ -0,0 +1,47 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared/macro"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	pkg := NewPackage()
+	pkg.Name = "nano"
+	pkg.Version = "5.6.1"
+	pkg.Environment = "export LC_ALL=C"
+	pkg.Stages.Setup = "%configure"
+	script, err := pkg.Script("setup", macro.Context{Emul32: true})
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	for _, line := range []string{"export version='5.6.1'", "export LC_ALL=C"} {
+		if !strings.Contains(script, line+"\n") {
+			t.Errorf("expected '%s' in script, found: %s", line, script)
+		}
+	}
+	if !strings.Contains(script, "--libdir=/usr/lib32 ") || !strings.Contains(script, "--libexecdir=/usr/lib32/nano") {
+		t.Errorf("expected 32-bit directories in script, found: %s", script)
+	}
+	if _, err = pkg.Script("package", macro.Context{}); !errors.Is(err, ErrUnknownStage) {
+		t.Errorf("expected '%s', found: %v", ErrUnknownStage, err)
+	}
+}
@@ -24,3 +24,26 @@ type BuildStages struct {
 	Check   string `yaml:"check,omitempty"`
 	Install string `yaml:"install"`
 }
+
+// StageNames are the names of every build stage, in the order they are written
+var StageNames = []string{"setup", "build", "profile", "check", "install"}
+
+// Stage gets the script for a build stage by name
+func (stages BuildStages) Stage(name string) (script string, ok bool) {
+	ok = true
+	switch name {
+	case "setup":
+		script = stages.Setup
+	case "build":
+		script = stages.Build
+	case "profile":
+		script = stages.Profile
+	case "check":
+		script = stages.Check
+	case "install":
+		script = stages.Install
+	default:
+		ok = false
+	}
+	return
+}
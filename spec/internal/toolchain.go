@@ -0,0 +1,290 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared/macro"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrUnknownOptimization indicates an optimize value which is not one of the Optimizations
+	ErrUnknownOptimization = errors.New("unknown optimization")
+	// ErrClangOnly indicates an optimize value which can only be used when building with clang
+	ErrClangOnly = errors.New("optimization requires 'clang: yes'")
+)
+
+// Arch is the kind of binaries built by a single pass of the build
+type Arch int
+
+const (
+	// Native builds 64-bit binaries for any x86_64 processor
+	Native Arch = iota
+	// Emul32 builds 32-bit libraries
+	Emul32
+	// AVX2 builds 64-bit libraries for processors with AVX2
+	AVX2
+)
+
+// String gets the name of this Arch
+func (a Arch) String() string {
+	switch a {
+	case Emul32:
+		return "emul32"
+	case AVX2:
+		return "avx2"
+	}
+	return "native"
+}
+
+var (
+	// baseCFLAGS are the C compiler flags used for every package before any changes
+	baseCFLAGS = []string{
+		"-mtune=generic", "-march=x86-64", "-g2", "-O2", "-pipe", "-fno-plt", "-fPIC",
+		"-Wformat", "-Wformat-security", "-D_FORTIFY_SOURCE=2", "-fstack-protector-strong",
+		"--param=ssp-buffer-size=32", "-fasynchronous-unwind-tables", "-ftree-vectorize",
+		"-feliminate-unused-debug-types", "-Wall", "-Wno-error", "-Wp,-D_REENTRANT",
+	}
+	// baseLDFLAGS are the linker flags used for every package before any changes
+	baseLDFLAGS = []string{
+		"-Wl,--copy-dt-needed-entries", "-Wl,-O1", "-Wl,-z,relro", "-Wl,-z,now",
+		"-Wl,-z,max-page-size=0x1000", "-Wl,-Bsymbolic-functions", "-Wl,--sort-common",
+	}
+	// gccOnly are the flags which clang does not understand
+	gccOnly = []string{"--param=ssp-buffer-size=32", "-feliminate-unused-debug-types"}
+)
+
+// Toolchain is the compiler and flags used for a single pass of the build
+type Toolchain struct {
+	// Arch is the kind of binaries being built
+	Arch Arch
+	// Clang is set when building with clang rather than gcc
+	Clang bool
+	// CC is the C compiler
+	CC string
+	// CXX is the C++ compiler
+	CXX string
+	// AR is the archiver for static libraries
+	AR string
+	// RANLIB indexes static libraries
+	RANLIB string
+	// CFLAGS are the flags for the C compiler
+	CFLAGS []string
+	// CXXFLAGS are the flags for the C++ compiler
+	CXXFLAGS []string
+	// LDFLAGS are the flags for the linker
+	LDFLAGS []string
+}
+
+// replace swaps one flag for another, adding it if the old flag is missing
+func replace(flags []string, old, new string) []string {
+	for i, flag := range flags {
+		if flag == old {
+			out := append([]string(nil), flags...)
+			out[i] = new
+			return out
+		}
+	}
+	return append(flags, new)
+}
+
+// remove drops every one of the unwanted flags
+func remove(flags []string, unwanted ...string) (out []string) {
+	for _, flag := range flags {
+		keep := true
+		for _, u := range unwanted {
+			keep = keep && flag != u
+		}
+		if keep {
+			out = append(out, flag)
+		}
+	}
+	return
+}
+
+// compile adds flags for both the C and C++ compilers
+func (t *Toolchain) compile(flags ...string) {
+	t.CFLAGS = append(t.CFLAGS, flags...)
+	t.CXXFLAGS = append(t.CXXFLAGS, flags...)
+}
+
+// setLevel replaces the optimization level, like "-O2", in a list of flags
+func setLevel(flags []string, level string) []string {
+	for _, flag := range flags {
+		if len(flag) == 3 && strings.HasPrefix(flag, "-O") {
+			return replace(flags, flag, level)
+		}
+	}
+	return append(flags, level)
+}
+
+// level replaces the optimization level of both compilers
+func (t *Toolchain) level(flag string) {
+	t.CFLAGS = setLevel(t.CFLAGS, flag)
+	t.CXXFLAGS = setLevel(t.CXXFLAGS, flag)
+}
+
+// optimizations change a Toolchain for each of the Optimizations, like ypkg
+var optimizations = map[string]func(t *Toolchain) error{
+	"speed": func(t *Toolchain) error {
+		t.level("-O3")
+		if !t.Clang {
+			t.compile("-ffunction-sections", "-fno-semantic-interposition", "-falign-functions=32")
+		}
+		return nil
+	},
+	"size": func(t *Toolchain) error {
+		t.level("-Os")
+		if !t.Clang {
+			t.compile("-ffunction-sections")
+		}
+		return nil
+	},
+	"lto": func(t *Toolchain) error {
+		t.compile("-flto")
+		t.LDFLAGS = append(t.LDFLAGS, "-flto")
+		if !t.Clang {
+			t.AR, t.RANLIB = "gcc-ar", "gcc-ranlib"
+		}
+		return nil
+	},
+	"thin-lto": func(t *Toolchain) error {
+		if !t.Clang {
+			return fmt.Errorf("%w: thin-lto", ErrClangOnly)
+		}
+		t.compile("-flto=thin")
+		t.LDFLAGS = append(t.LDFLAGS, "-flto=thin")
+		return nil
+	},
+	"unroll-loops": func(t *Toolchain) error {
+		t.compile("-funroll-loops")
+		return nil
+	},
+	"no-bind-now": func(t *Toolchain) error {
+		t.LDFLAGS = remove(t.LDFLAGS, "-Wl,-z,now", "-Wl,-z,relro")
+		return nil
+	},
+	"no-symbolic": func(t *Toolchain) error {
+		t.LDFLAGS = remove(t.LDFLAGS, "-Wl,-Bsymbolic-functions")
+		return nil
+	},
+	"no-frame-pointer": func(t *Toolchain) error {
+		t.compile("-fomit-frame-pointer")
+		return nil
+	},
+	"runpath": func(t *Toolchain) error {
+		t.LDFLAGS = append(t.LDFLAGS, "-Wl,--enable-new-dtags")
+		return nil
+	},
+	"avx256": func(t *Toolchain) error {
+		// Only the AVX2 pass can use wider vectors
+		if t.Arch == AVX2 {
+			t.compile("-mprefer-vector-width=256")
+		}
+		return nil
+	},
+	"sse4": func(t *Toolchain) error {
+		if t.Arch == Native {
+			t.compile("-msse4")
+		}
+		return nil
+	},
+	"icf-safe": func(t *Toolchain) error {
+		t.LDFLAGS = append(t.LDFLAGS, "-Wl,--icf=safe")
+		return nil
+	},
+	"icf-all": func(t *Toolchain) error {
+		t.LDFLAGS = append(t.LDFLAGS, "-Wl,--icf=all")
+		return nil
+	},
+	"polly": func(t *Toolchain) error {
+		if !t.Clang {
+			return fmt.Errorf("%w: polly", ErrClangOnly)
+		}
+		t.compile("-mllvm", "-polly")
+		return nil
+	},
+	"function-sections": func(t *Toolchain) error {
+		t.compile("-ffunction-sections")
+		return nil
+	},
+}
+
+// Resolve finds the Toolchain for a single pass of the build, applying every optimization in order
+func (flags BuildFlags) Resolve(arch Arch) (t Toolchain, err error) {
+	t = Toolchain{
+		Arch:     arch,
+		Clang:    flags.Clang.Value(),
+		CC:       "gcc",
+		CXX:      "g++",
+		AR:       "ar",
+		RANLIB:   "ranlib",
+		CFLAGS:   append([]string(nil), baseCFLAGS...),
+		CXXFLAGS: append([]string(nil), baseCFLAGS...),
+		LDFLAGS:  append([]string(nil), baseLDFLAGS...),
+	}
+	if t.Clang {
+		t.CC, t.CXX, t.AR, t.RANLIB = "clang", "clang++", "llvm-ar", "llvm-ranlib"
+		t.CFLAGS = remove(t.CFLAGS, gccOnly...)
+		t.CXXFLAGS = remove(t.CXXFLAGS, gccOnly...)
+	}
+	switch arch {
+	case Emul32:
+		t.CC, t.CXX = t.CC+" -m32", t.CXX+" -m32"
+		t.CFLAGS = replace(t.CFLAGS, "-march=x86-64", "-march=i686")
+		t.CXXFLAGS = replace(t.CXXFLAGS, "-march=x86-64", "-march=i686")
+	case AVX2:
+		t.CFLAGS = replace(replace(t.CFLAGS, "-march=x86-64", "-march=haswell"), "-mtune=generic", "-mtune=haswell")
+		t.CXXFLAGS = replace(replace(t.CXXFLAGS, "-march=x86-64", "-march=haswell"), "-mtune=generic", "-mtune=haswell")
+	}
+	if !flags.Debug.Value() {
+		t.CFLAGS = remove(t.CFLAGS, "-g2")
+		t.CXXFLAGS = remove(t.CXXFLAGS, "-g2")
+	}
+	for _, name := range flags.Optimize {
+		apply, ok := optimizations[name]
+		if !ok {
+			err = fmt.Errorf("%w: '%s'", ErrUnknownOptimization, name)
+			return
+		}
+		if err = apply(&t); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Context gets the macro.Context for building a package with this Toolchain
+func (t Toolchain) Context() macro.Context {
+	return macro.Context{
+		Emul32:   t.Arch == Emul32,
+		AVX2:     t.Arch == AVX2,
+		Clang:    t.Clang,
+		CFLAGS:   strings.Join(t.CFLAGS, " "),
+		CXXFLAGS: strings.Join(t.CXXFLAGS, " "),
+		LDFLAGS:  strings.Join(t.LDFLAGS, " "),
+	}
+}
+
+// String describes the compilers and flags of this Toolchain, one per line
+func (t Toolchain) String() string {
+	return fmt.Sprintf("CC=%s\nCXX=%s\nAR=%s\nRANLIB=%s\nCFLAGS=%s\nCXXFLAGS=%s\nLDFLAGS=%s\n",
+		t.CC, t.CXX, t.AR, t.RANLIB,
+		strings.Join(t.CFLAGS, " "), strings.Join(t.CXXFLAGS, " "), strings.Join(t.LDFLAGS, " "))
+}
@@ -0,0 +1,118 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// has checks if a list of flags contains a single flag
+func has(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+func TestOptimizationsResolve(t *testing.T) {
+	for _, name := range Optimizations {
+		if _, ok := optimizations[name]; !ok {
+			t.Errorf("expected '%s' to be resolved", name)
+		}
+	}
+	if len(optimizations) != len(Optimizations) {
+		t.Errorf("expected %d optimizations, found: %d", len(Optimizations), len(optimizations))
+	}
+}
+
+func TestResolve(t *testing.T) {
+	flags := BuildFlags{
+		Clang:    shared.DefaultTrue{Valid: true, Bool: false},
+		Optimize: []string{"size", "speed", "lto", "no-bind-now", "no-symbolic"},
+	}
+	tc, err := flags.Resolve(Native)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if tc.CC != "gcc" || tc.AR != "gcc-ar" {
+		t.Errorf("expected '%s', found: %s", "gcc", tc.CC)
+	}
+	for _, flag := range []string{"-O3", "-flto", "-fno-semantic-interposition", "--param=ssp-buffer-size=32"} {
+		if !has(tc.CFLAGS, flag) {
+			t.Errorf("expected '%s' in CFLAGS, found: %v", flag, tc.CFLAGS)
+		}
+	}
+	for _, flag := range []string{"-O2", "-Os"} {
+		if has(tc.CFLAGS, flag) {
+			t.Errorf("expected no '%s' in CFLAGS, found: %v", flag, tc.CFLAGS)
+		}
+	}
+	for _, flag := range []string{"-Wl,-z,now", "-Wl,-Bsymbolic-functions"} {
+		if has(tc.LDFLAGS, flag) {
+			t.Errorf("expected no '%s' in LDFLAGS, found: %v", flag, tc.LDFLAGS)
+		}
+	}
+}
+
+func TestResolveArch(t *testing.T) {
+	flags := BuildFlags{
+		Debug:    shared.DefaultTrue{Valid: true, Bool: false},
+		Optimize: []string{"avx256", "thin-lto"},
+	}
+	emul32, err := flags.Resolve(Emul32)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if emul32.CC != "clang -m32" || !has(emul32.CFLAGS, "-march=i686") || has(emul32.CFLAGS, "-mprefer-vector-width=256") {
+		t.Errorf("expected a 32-bit clang toolchain, found: %s", emul32)
+	}
+	if has(emul32.CFLAGS, "-g2") || has(emul32.CFLAGS, "-feliminate-unused-debug-types") {
+		t.Errorf("expected no debug or gcc flags, found: %v", emul32.CFLAGS)
+	}
+	avx2, err := flags.Resolve(AVX2)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if !has(avx2.CFLAGS, "-march=haswell") || !has(avx2.CFLAGS, "-mprefer-vector-width=256") || !has(avx2.LDFLAGS, "-flto=thin") {
+		t.Errorf("expected a haswell toolchain, found: %s", avx2)
+	}
+	ctx := avx2.Context()
+	if !ctx.AVX2 || !ctx.Clang || !strings.Contains(ctx.CFLAGS, "-march=haswell") {
+		t.Errorf("expected an AVX2 context, found: %v", ctx)
+	}
+}
+
+func TestResolveInvalid(t *testing.T) {
+	if _, err := (BuildFlags{Optimize: []string{"sped"}}).Resolve(Native); !errors.Is(err, ErrUnknownOptimization) {
+		t.Errorf("expected '%s', found: %v", ErrUnknownOptimization, err)
+	}
+	gcc := shared.DefaultTrue{Valid: true, Bool: false}
+	if _, err := (BuildFlags{Clang: gcc, Optimize: []string{"polly"}}).Resolve(Native); !errors.Is(err, ErrClangOnly) {
+		t.Errorf("expected '%s', found: %v", ErrClangOnly, err)
+	}
+	pkg := lintable(t)
+	pkg.Flags.Optimize = []string{"speed", "sped"}
+	ps, ok := pkg.Lint().(Problems)
+	if !ok || len(ps) != 1 || ps[0].Rule != "invalid-optimize" || ps[0].Line != 2 {
+		t.Errorf("expected '%s' on '%s:%d', found: %v", "invalid-optimize", "flags.optimize", 2, ps)
+	}
+}
@@ -0,0 +1,120 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"dev.getsol.us/source/libypkg.git/spec/shared/version"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrVersionNotFound indicates that none of the existing sources contain the old version
+	ErrVersionNotFound = errors.New("old version not found in any source, new sources are required")
+	// ErrDowngrade indicates an attempt to update a package to an older version
+	ErrDowngrade = errors.New("new version is older than the current version")
+)
+
+// isDigit checks if a byte is an ASCII digit
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// replaceBounded replaces every "old" in "s" that is not part of a longer number
+func replaceBounded(s, old, new string) string {
+	if len(old) == 0 {
+		return s
+	}
+	var out strings.Builder
+	for {
+		i := strings.Index(s, old)
+		if i < 0 {
+			break
+		}
+		end := i + len(old)
+		before := i > 0 && (isDigit(s[i-1]) || s[i-1] == '.')
+		after := end < len(s) && (isDigit(s[end]) || (s[end] == '.' && end+1 < len(s) && isDigit(s[end+1])))
+		out.WriteString(s[:i])
+		if before || after {
+			out.WriteString(old)
+		} else {
+			out.WriteString(new)
+		}
+		s = s[end:]
+	}
+	out.WriteString(s)
+	return out.String()
+}
+
+// series gets the "major.minor" prefix of a version, used for versioned directories
+func series(version string) string {
+	pieces := strings.SplitN(version, ".", 3)
+	if len(pieces) < 3 {
+		return ""
+	}
+	return pieces[0] + "." + pieces[1]
+}
+
+// ReplaceVersion rewrites a source URI for a new version of the package
+//
+// Every occurrence of the old version is substituted, including the forms used by
+// some projects where the dots are replaced by underscores or dashes. Versioned
+// directories like "/3.38/" are updated to the series of the new version.
+func ReplaceVersion(URI, old, new string) string {
+	URI = replaceBounded(URI, old, new)
+	if strings.Contains(old, ".") {
+		for _, sep := range []string{"_", "-"} {
+			URI = replaceBounded(URI, strings.ReplaceAll(old, ".", sep), strings.ReplaceAll(new, ".", sep))
+		}
+	}
+	oldSeries, newSeries := series(old), series(new)
+	if len(oldSeries) > 0 && len(newSeries) > 0 {
+		URI = strings.ReplaceAll(URI, "/"+oldSeries+"/", "/"+newSeries+"/")
+	}
+	return URI
+}
+
+// checkDowngrade makes sure that a new version is not older than the current one
+func (pkg *PackageYML) checkDowngrade(next string) error {
+	if len(pkg.Version) > 0 && version.Compare(next, pkg.Version) < 0 {
+		return fmt.Errorf("%w: %s is older than %s", ErrDowngrade, next, pkg.Version)
+	}
+	return nil
+}
+
+// nextSources gets the URIs of the existing sources, rewritten for a new version
+func (pkg *PackageYML) nextSources(version string) (URIs []string, err error) {
+	var found bool
+	for _, src := range pkg.Source {
+		// Pinned Git sources keep their original reference in a comment
+		if src.IsGit() && shared.IsCommit(src.Ref) && strings.HasPrefix(src.Comment, "# ") {
+			src.Ref = strings.TrimPrefix(src.Comment, "# ")
+		}
+		URI := src.String()
+		next := ReplaceVersion(URI, pkg.Version, version)
+		if next != URI {
+			found = true
+		}
+		URIs = append(URIs, next)
+	}
+	if !found {
+		err = ErrVersionNotFound
+	}
+	return
+}
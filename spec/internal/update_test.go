@@ -0,0 +1,120 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package internal
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestReplaceVersion(t *testing.T) {
+	cases := []struct {
+		URI, old, new, expected string
+	}{
+		{
+			"https://dl.google.com/go/go1.16.2.src.tar.gz", "1.16.2", "1.16.3",
+			"https://dl.google.com/go/go1.16.3.src.tar.gz",
+		},
+		{
+			"https://github.com/DataDrake/cuppa/archive/v1.0.1.tar.gz", "1.0.1", "1.1.0",
+			"https://github.com/DataDrake/cuppa/archive/v1.1.0.tar.gz",
+		},
+		{
+			"https://download.gnome.org/sources/gedit/3.38/gedit-3.38.1.tar.xz", "3.38.1", "40.0.1",
+			"https://download.gnome.org/sources/gedit/40.0/gedit-40.0.1.tar.xz",
+		},
+		{
+			"https://boostorg.jfrog.io/release/1.75.0/source/boost_1_75_0.tar.bz2", "1.75.0", "1.76.0",
+			"https://boostorg.jfrog.io/release/1.76.0/source/boost_1_76_0.tar.bz2",
+		},
+		{
+			"https://example.com/foo-11.0.tar.gz", "1.0", "1.1",
+			"https://example.com/foo-11.0.tar.gz",
+		},
+		{
+			"git|https://github.com/DataDrake/cuppa.git:v1.0.1", "1.0.1", "1.0.2",
+			"git|https://github.com/DataDrake/cuppa.git:v1.0.2",
+		},
+	}
+	for _, c := range cases {
+		if result := ReplaceVersion(c.URI, c.old, c.new); result != c.expected {
+			t.Errorf("expected '%s', found: %s", c.expected, result)
+		}
+	}
+}
+
+func TestUpdateFromVersion(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"foo-1.0.tar.gz", "foo-1.1.tar.gz"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Expected no error, found: %s", err)
+		}
+	}
+	src, err := shared.ParseSourceURI("file://" + filepath.Join(dir, "foo-1.0.tar.gz"))
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	pkg := NewPackage()
+	pkg.Version = "1.0"
+	pkg.Source = []shared.SourceURI{src}
+	if err = pkg.Update("1.1", nil, nil); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if pkg.Version != "1.1" {
+		t.Errorf("expected '%s', found: %s", "1.1", pkg.Version)
+	}
+	if len(pkg.Source) != 1 {
+		t.Fatalf("expected 1 source, found: %d", len(pkg.Source))
+	}
+	expected := "file://" + filepath.Join(dir, "foo-1.1.tar.gz")
+	if URL := pkg.Source[0].URL; URL != expected {
+		t.Errorf("expected '%s', found: %s", expected, URL)
+	}
+	if pkg.Source[0].Digest.IsEmpty() {
+		t.Error("expected a new hash")
+	}
+}
+
+func TestUpdateVersionNotFound(t *testing.T) {
+	src, err := shared.ParseSourceURI("https://example.com/latest.tar.gz")
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	pkg := NewPackage()
+	pkg.Version = "1.0"
+	pkg.Source = []shared.SourceURI{src}
+	if err = pkg.Update("1.1", nil, nil); err != ErrVersionNotFound {
+		t.Fatalf("expected ErrVersionNotFound, found: %v", err)
+	}
+	if pkg.Version != "1.0" {
+		t.Errorf("expected '%s', found: %s", "1.0", pkg.Version)
+	}
+}
+
+func TestUpdateDowngrade(t *testing.T) {
+	pkg := NewPackage()
+	pkg.Version = "1.0"
+	if err := pkg.Update("1.0rc1", []string{"https://example.com/foo-1.0rc1.tar.gz"}, nil); !errors.Is(err, ErrDowngrade) {
+		t.Fatalf("expected ErrDowngrade, found: %v", err)
+	}
+	if pkg.Version != "1.0" {
+		t.Errorf("expected '%s', found: %s", "1.0", pkg.Version)
+	}
+}
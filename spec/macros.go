@@ -0,0 +1,37 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/internal"
+	"dev.getsol.us/source/libypkg.git/spec/shared/macro"
+)
+
+// Macros gets the Catalog used to check and expand build stages
+func Macros() *macro.Catalog {
+	return internal.Macros
+}
+
+// LoadMacros replaces the standard Catalog with one read from a macro definition file, like ypkg's rc.yml
+func LoadMacros(path string) error {
+	c, err := macro.Load(path)
+	if err != nil {
+		return err
+	}
+	internal.Macros = c
+	return nil
+}
@@ -0,0 +1,152 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/internal"
+	"errors"
+	"gopkg.in/yaml.v3"
+	"strings"
+	"testing"
+)
+
+// mergeTest writes out the three sides of a merge and merges them
+func mergeTest(t *testing.T, base, ours, theirs string) (*internal.PackageYML, error) {
+	dir := t.TempDir()
+	o := writeHeaderTest(t, dir, "ours.yml", ours)
+	err := MergeFiles(writeHeaderTest(t, dir, "base.yml", base), o, writeHeaderTest(t, dir, "theirs.yml", theirs))
+	merged, lerr := loadInternal(o)
+	if lerr != nil {
+		t.Fatalf("Expected no error, found: %s", lerr)
+	}
+	return merged, err
+}
+
+func TestMergeFiles(t *testing.T) {
+	ours := strings.Replace(headerV3, "release: 142", "release: 143", 1)
+	ours = strings.Replace(ours, "        - golang\n    run:", "        - golang\n        - git\n    run:", 1)
+	theirs := strings.Replace(headerV3, "release: 142", "release: 143", 1)
+	theirs = strings.Replace(theirs, "        - devel:\n            - golang\n", "        - devel:\n            - golang\n            - gcc\n", 1)
+	theirs = strings.Replace(theirs, "    cp -a * $installdir/%libdir%/golang/\n", "    cp -a * $installdir/%libdir%/golang/\n    rm -rf $installdir/%libdir%/golang/test\n", 1)
+	merged, err := mergeTest(t, headerV3, ours, theirs)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if merged.Release != 144 {
+		t.Errorf("expected '%d', found: %d", 144, merged.Release)
+	}
+	if build := nodeValues(merged.Dependencies.Build); build != "golang git" {
+		t.Errorf("expected '%s', found: %s", "golang git", build)
+	}
+	var run []string
+	for _, node := range merged.Dependencies.Run["devel"] {
+		run = append(run, node.Value)
+	}
+	if strings.Join(run, " ") != "golang gcc" {
+		t.Errorf("expected '%s', found: %v", "golang gcc", run)
+	}
+	if !strings.HasSuffix(merged.Stages.Install, "rm -rf $installdir/%libdir%/golang/test\n") {
+		t.Errorf("expected the install stage from theirs, found:\n%s", merged.Stages.Install)
+	}
+}
+
+func TestMergeFilesConflict(t *testing.T) {
+	ours := strings.Replace(headerV3, "    cp -a * $installdir", "    cp -r * $installdir", 1)
+	theirs := strings.Replace(headerV3, "    cp -a * $installdir", "    cp -R * $installdir", 1)
+	theirs = strings.Replace(theirs, "release: 142", "release: 143", 1)
+	merged, err := mergeTest(t, headerV3, ours, theirs)
+	var conflicts internal.Conflicts
+	if !errors.As(err, &conflicts) {
+		t.Fatalf("expected Conflicts, found: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Field != "install" {
+		t.Fatalf("expected a conflict in install, found: %s", conflicts)
+	}
+	if merged.Release != 143 {
+		t.Errorf("expected '%d', found: %d", 143, merged.Release)
+	}
+	if !strings.Contains(merged.Stages.Install, "<<<<<<< ours\ncp -r * $installdir/%libdir%/golang/\n=======\ncp -R") {
+		t.Errorf("expected conflict markers, found:\n%s", merged.Stages.Install)
+	}
+}
+
+func TestMergeFilesConflictOrder(t *testing.T) {
+	summary := func(prefix string) string {
+		lines := "summary    :\n    - " + prefix + " Go\n"
+		for _, name := range []string{"libs", "devel", "docs", "32bit"} {
+			lines += "    - " + name + ": " + prefix + " " + name + "\n"
+		}
+		return strings.Replace(headerV2, "summary    : Go Programming Language\n", lines, 1)
+	}
+	_, err := mergeTest(t, summary("Base"), summary("Ours"), summary("Theirs"))
+	var conflicts internal.Conflicts
+	if !errors.As(err, &conflicts) {
+		t.Fatalf("expected Conflicts, found: %v", err)
+	}
+	expected := []string{"", "32bit", "devel", "docs", "libs"}
+	if len(conflicts) != len(expected) {
+		t.Fatalf("expected %d conflicts, found: %s", len(expected), conflicts)
+	}
+	for i, c := range conflicts {
+		if c.Field != "summaries" || c.Package != expected[i] {
+			t.Errorf("expected '%s', found: %s", expected[i], c)
+		}
+	}
+}
+
+func TestMergeFilesFlags(t *testing.T) {
+	ours := headerV3 + "flags:\n    clang: yes\n"
+	theirs := headerV3 + "flags:\n    clang: no\n"
+	merged, err := mergeTest(t, headerV3, ours, theirs)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if merged.Flags.Clang.Value() {
+		t.Errorf("expected '%s', found: %t", "clang: no", merged.Flags.Clang.Value())
+	}
+}
+
+func TestMergeFilesSourceRemoved(t *testing.T) {
+	old := "    - https://dl.google.com/go/go1.16.2.src.tar.gz : 37ca14287a23cb8ba2ac3f5c3dd8adbc1f7a54b9701a57824bf19a0b271f83ea\n"
+	ours := strings.Replace(headerV3, "37ca14287a23", "47ca14287a23", 1)
+	theirs := strings.Replace(headerV3, old, "    - https://dl.google.com/go/go1.16.3.src.tar.gz : 0123456789abcdef\n", 1)
+	merged, err := mergeTest(t, headerV3, ours, theirs)
+	var conflicts internal.Conflicts
+	if !errors.As(err, &conflicts) {
+		t.Fatalf("expected Conflicts, found: %v", err)
+	}
+	field := "source[https://dl.google.com/go/go1.16.2.src.tar.gz]"
+	if len(conflicts) != 1 || conflicts[0].Field != field {
+		t.Fatalf("expected a conflict in '%s', found: %s", field, conflicts)
+	}
+	if len(merged.Source) != 2 || !strings.HasPrefix(merged.Source[0].Digest.Value, "47ca") {
+		t.Errorf("expected the changed source to be kept, found: %v", merged.Source)
+	}
+	merged, err = mergeTest(t, headerV3, theirs, ours)
+	if !errors.As(err, &conflicts) || len(merged.Source) != 2 {
+		t.Errorf("expected a conflict keeping both sources, found: %v %v", err, merged.Source)
+	}
+}
+
+// nodeValues joins the values of a list of nodes
+func nodeValues(nodes []yaml.Node) string {
+	var vs []string
+	for _, node := range nodes {
+		vs = append(vs, node.Value)
+	}
+	return strings.Join(vs, " ")
+}
@@ -0,0 +1,168 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/v2"
+	"dev.getsol.us/source/libypkg.git/spec/v3"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strings"
+)
+
+// SchemaDraft is the version of JSON Schema used by Schema
+const SchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// schemer is implemented by types with custom YAML marshaling, to describe what they accept
+type schemer interface {
+	JSONSchema() map[string]interface{}
+}
+
+var (
+	// schemerType is used to find the types which describe themselves
+	schemerType = reflect.TypeOf((*schemer)(nil)).Elem()
+	// nodeType is used to find raw YAML, which is always a scalar in package.yml
+	nodeType = reflect.TypeOf(yaml.Node{})
+)
+
+// packageTypes are the PackageYML types of each supported format
+var packageTypes = map[int]reflect.Type{
+	2: reflect.TypeOf(v2.PackageYML{}),
+	3: reflect.TypeOf(v3.PackageYML{}),
+}
+
+// Schema generates a JSON Schema document for a supported version of the package.yml format
+func Schema(ypkg int) ([]byte, error) {
+	t, ok := packageTypes[ypkg]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrInvalidVersion, ypkg)
+	}
+	s := typeSchema(t)
+	s["$schema"] = SchemaDraft
+	s["title"] = fmt.Sprintf("package.yml (YPKG %d)", ypkg)
+	// v2 files may still declare their version, even though it is not part of the format
+	props := s["properties"].(map[string]interface{})
+	props["YPKG"] = map[string]interface{}{
+		"type":  "integer",
+		"const": ypkg,
+	}
+	for _, f := range Fields(ypkg) {
+		annotate(s, f)
+	}
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// annotate adds the documentation of a Field to its property in a schema
+func annotate(s map[string]interface{}, f Field) {
+	for _, name := range strings.Split(f.Path, ".") {
+		props, ok := s["properties"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		if s, ok = props[name].(map[string]interface{}); !ok {
+			return
+		}
+	}
+	if len(f.Description) > 0 {
+		s["description"] = f.Description
+	}
+	var example interface{}
+	if err := yaml.Unmarshal([]byte(f.Example), &example); err == nil && example != nil {
+		s["examples"] = []interface{}{example}
+	}
+	if len(f.Values) > 0 && f.Path != "YPKG" {
+		if items, ok := s["items"].(map[string]interface{}); ok && s["type"] == "array" {
+			items["enum"] = f.Values
+		} else {
+			s["enum"] = f.Values
+		}
+	}
+}
+
+// scalarTypes are accepted for string fields, since unquoted values like "version: 1.0" are read as numbers
+var scalarTypes = []string{"string", "number"}
+
+// typeSchema describes the YAML accepted for a Go type
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t.Implements(schemerType) {
+		return reflect.Zero(t).Interface().(schemer).JSONSchema()
+	}
+	if t == nodeType {
+		return map[string]interface{}{"type": scalarTypes}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": scalarTypes}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem()),
+		}
+	case reflect.Struct:
+		s := map[string]interface{}{
+			"type":                 "object",
+			"properties":           make(map[string]interface{}),
+			"additionalProperties": false,
+		}
+		var required []string
+		fields(t, s["properties"].(map[string]interface{}), &required)
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	}
+	return map[string]interface{}{}
+}
+
+// fields adds the properties of a struct to a schema, following inline structs like yaml.v3 does
+func fields(t reflect.Type, props map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("yaml"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		var inline, omitempty bool
+		for _, opt := range tag[1:] {
+			inline = inline || opt == "inline"
+			omitempty = omitempty || opt == "omitempty"
+		}
+		if inline {
+			fields(field.Type, props, required)
+			continue
+		}
+		if len(name) == 0 {
+			name = strings.ToLower(field.Name)
+		}
+		props[name] = typeSchema(field.Type)
+		if !omitempty {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// loadSchema generates the schema for a version of the format and reads it back in
+func loadSchema(t *testing.T, ypkg int) (s map[string]interface{}) {
+	data, err := Schema(ypkg)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if err = json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	return
+}
+
+// property gets a nested property of a schema
+func property(s map[string]interface{}, names ...string) map[string]interface{} {
+	for _, name := range names {
+		props, _ := s["properties"].(map[string]interface{})
+		s, _ = props[name].(map[string]interface{})
+	}
+	return s
+}
+
+func TestSchemaKeys(t *testing.T) {
+	for _, ypkg := range []int{2, 3} {
+		s := loadSchema(t, ypkg)
+		for _, key := range Keys(ypkg, "") {
+			if property(s, key) == nil {
+				t.Errorf("expected '%s' in the v%d schema", key, ypkg)
+			}
+		}
+		if s["additionalProperties"] != false {
+			t.Errorf("expected no additional properties in the v%d schema", ypkg)
+		}
+	}
+	if property(loadSchema(t, 2), "deps") != nil {
+		t.Errorf("expected no '%s' in the v2 schema", "deps")
+	}
+}
+
+func TestSchemaRequired(t *testing.T) {
+	s := loadSchema(t, 3)
+	required := make(map[string]bool)
+	for _, name := range s["required"].([]interface{}) {
+		required[name.(string)] = true
+	}
+	for _, name := range []string{"YPKG", "name", "version", "release", "source", "license", "install"} {
+		if !required[name] {
+			t.Errorf("expected '%s' to be required", name)
+		}
+	}
+	for _, name := range []string{"homepage", "flags", "setup"} {
+		if required[name] {
+			t.Errorf("expected '%s' to be optional", name)
+		}
+	}
+	if version := property(s, "YPKG")["const"]; version != 3.0 {
+		t.Errorf("expected '%d', found: %v", 3, version)
+	}
+}
+
+func TestSchemaCustomTypes(t *testing.T) {
+	s := loadSchema(t, 3)
+	if def := property(s, "flags", "clang")["default"]; def != true {
+		t.Errorf("expected '%t', found: %v", true, def)
+	}
+	if def := property(s, "flags", "avx2")["default"]; def != false {
+		t.Errorf("expected '%t', found: %v", false, def)
+	}
+	for _, name := range []string{"license", "components"} {
+		if _, ok := property(s, name)["oneOf"]; !ok {
+			t.Errorf("expected '%s' to be a string or a list", name)
+		}
+	}
+	if kind := property(s, "patterns")["type"]; kind != "array" {
+		t.Errorf("expected '%s', found: %v", "array", kind)
+	}
+	source := property(s, "source")["items"].(map[string]interface{})
+	if max := source["maxProperties"]; max != 1.0 {
+		t.Errorf("expected '%d', found: %v", 1, max)
+	}
+}
+
+func TestSchemaNumbers(t *testing.T) {
+	for _, ypkg := range []int{2, 3} {
+		s := loadSchema(t, ypkg)
+		for _, name := range []string{"version", "name", "homepage"} {
+			kinds, ok := property(s, name)["type"].([]interface{})
+			if !ok || len(kinds) != 2 || kinds[0] != "string" || kinds[1] != "number" {
+				t.Errorf("expected '%s' to be a string or number, found: %v", name, property(s, name)["type"])
+			}
+		}
+	}
+}
+
+func TestSchemaDocumented(t *testing.T) {
+	s := loadSchema(t, 3)
+	emul32 := property(s, "flags", "emul32")
+	if _, ok := emul32["description"]; !ok {
+		t.Errorf("expected a description for '%s'", "flags.emul32")
+	}
+	optimize := property(s, "flags", "optimize")["items"].(map[string]interface{})
+	if values, ok := optimize["enum"].([]interface{}); !ok || len(values) != len(Optimizations) {
+		t.Errorf("expected %d values for '%s', found: %v", len(Optimizations), "flags.optimize", optimize["enum"])
+	}
+}
+
+func TestSchemaInvalidVersion(t *testing.T) {
+	if _, err := Schema(4); !errors.Is(err, ErrInvalidVersion) {
+		t.Errorf("expected '%s', found: %v", ErrInvalidVersion, err)
+	}
+}
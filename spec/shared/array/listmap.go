@@ -116,3 +116,29 @@ func (am *ListMap) UnmarshalYAML(value *yaml.Node) error {
 	}
 	return nil
 }
+
+// JSONSchema describes a ListMap as a list of values and single entry maps of lists of values
+func (am ListMap) JSONSchema() map[string]interface{} {
+	value := map[string]interface{}{
+		"type":      "string",
+		"minLength": 1,
+	}
+	return map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"oneOf": []interface{}{
+				value,
+				map[string]interface{}{
+					"type":          "object",
+					"minProperties": 1,
+					"maxProperties": 1,
+					"additionalProperties": map[string]interface{}{
+						"type":     "array",
+						"minItems": 1,
+						"items":    value,
+					},
+				},
+			},
+		},
+	}
+}
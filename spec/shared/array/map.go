@@ -123,3 +123,27 @@ func (am *Map) UnmarshalYAML(value *yaml.Node) error {
 	}
 	return nil
 }
+
+// JSONSchema describes a Map as a single value, or a list of the main value followed by single entry maps
+func (am Map) JSONSchema() map[string]interface{} {
+	value := map[string]interface{}{
+		"type":      "string",
+		"minLength": 1,
+	}
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			value,
+			map[string]interface{}{
+				"type":     "array",
+				"minItems": 1,
+				"items":    []interface{}{value},
+				"additionalItems": map[string]interface{}{
+					"type":                 "object",
+					"minProperties":        1,
+					"maxProperties":        1,
+					"additionalProperties": value,
+				},
+			},
+		},
+	}
+}
@@ -32,6 +32,11 @@ type DefaultTrue struct {
 	Bool  bool
 }
 
+// Value gets the effective value of a DefaultTrue, which is true unless set otherwise
+func (dt DefaultTrue) Value() bool {
+	return !dt.Valid || dt.Bool
+}
+
 // MarshalYAML writes a DefaultTrue as "no" if false and omits it if empty or invalid
 func (dt DefaultTrue) MarshalYAML() (out interface{}, err error) {
 	node := yaml.Node{
@@ -68,6 +73,11 @@ type DefaultFalse struct {
 	Bool  bool
 }
 
+// Value gets the effective value of a DefaultFalse, which is false unless set otherwise
+func (df DefaultFalse) Value() bool {
+	return df.Valid && df.Bool
+}
+
 // MarshalYAML writes a DefaultFalse as "yes" if true and omits it if empty or invalid
 func (df DefaultFalse) MarshalYAML() (out interface{}, err error) {
 	node := yaml.Node{
@@ -97,3 +107,27 @@ func (df *DefaultFalse) UnmarshalYAML(value *yaml.Node) error {
 	}
 	return nil
 }
+
+// boolSchema describes the values accepted by UnmarshalYAML for DefaultTrue and DefaultFalse
+func boolSchema(def bool) map[string]interface{} {
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{"type": "boolean"},
+			map[string]interface{}{
+				"type": "string",
+				"enum": []string{"yes", "YES", "Yes", "True", "true", "no", "NO", "No", "False", "false"},
+			},
+		},
+		"default": def,
+	}
+}
+
+// JSONSchema describes a DefaultTrue as a yes/no boolean which defaults to true
+func (dt DefaultTrue) JSONSchema() map[string]interface{} {
+	return boolSchema(true)
+}
+
+// JSONSchema describes a DefaultFalse as a yes/no boolean which defaults to false
+func (df DefaultFalse) JSONSchema() map[string]interface{} {
+	return boolSchema(false)
+}
@@ -0,0 +1,163 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrCacheMiss indicates that an offline Cache does not contain the requested source
+var ErrCacheMiss = errors.New("source not found in cache")
+
+// Cache is an on-disk store of downloaded sources, addressed by their SHA256 hash
+//
+// Layout:
+// <Dir>/blobs/<hash of contents>
+// <Dir>/uris/<hash of URI>    (contains the hash of the contents)
+type Cache struct {
+	// Dir is the root directory of the cache
+	Dir string
+	// Offline prevents any downloads, sources must already be in the cache
+	Offline bool
+	// MaxSize is the total size in bytes of the cached sources before eviction, unlimited if zero
+	MaxSize int64
+}
+
+// NewCache creates a Cache rooted at a directory
+func NewCache(dir string) *Cache {
+	return &Cache{
+		Dir: dir,
+	}
+}
+
+// sum gets the hex encoded SHA256 hash of a string
+func sum(s string) string {
+	hash := crypto.SHA256.New()
+	_, _ = io.WriteString(hash, s)
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+// Path gets the location of the cached contents for a hash
+func (c *Cache) Path(hash string) string {
+	return filepath.Join(c.Dir, "blobs", hash)
+}
+
+// uriPath gets the location of the hash record for a URI
+func (c *Cache) uriPath(URI string) string {
+	return filepath.Join(c.Dir, "uris", sum(URI))
+}
+
+// Lookup gets the hash of a previously cached URI
+func (c *Cache) Lookup(URI string) (hash string, ok bool) {
+	raw, err := ioutil.ReadFile(c.uriPath(URI))
+	if err != nil {
+		return
+	}
+	hash = strings.TrimSpace(string(raw))
+	// Mark as recently used for eviction
+	now := time.Now()
+	if err = os.Chtimes(c.Path(hash), now, now); err != nil {
+		// Contents were evicted
+		_ = os.Remove(c.uriPath(URI))
+		hash = ""
+		return
+	}
+	ok = true
+	return
+}
+
+// Open gets a reader for the cached contents of a hash
+func (c *Cache) Open(hash string) (*os.File, error) {
+	return os.Open(c.Path(hash))
+}
+
+// Store saves the contents of a URI to the cache and returns their hash
+func (c *Cache) Store(URI string, in io.Reader) (hash string, err error) {
+	for _, dir := range []string{"blobs", "uris", "tmp"} {
+		if err = os.MkdirAll(filepath.Join(c.Dir, dir), 00755); err != nil {
+			return
+		}
+	}
+	tmp, err := ioutil.TempFile(filepath.Join(c.Dir, "tmp"), "download-")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	h := crypto.SHA256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), in)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return
+	}
+	hash = fmt.Sprintf("%x", h.Sum(nil))
+	if err = os.Rename(tmp.Name(), c.Path(hash)); err != nil {
+		return
+	}
+	if err = ioutil.WriteFile(c.uriPath(URI), []byte(hash+"\n"), 00644); err != nil {
+		return
+	}
+	err = c.evict(hash)
+	return
+}
+
+// Evict removes the least recently used contents until the cache is no larger than MaxSize
+func (c *Cache) Evict() error {
+	return c.evict("")
+}
+
+// evict removes the least recently used contents other than keep, so a newly stored
+// entry larger than MaxSize stays available until the next Store
+func (c *Cache) evict(keep string) error {
+	if c.MaxSize <= 0 {
+		return nil
+	}
+	infos, err := ioutil.ReadDir(filepath.Join(c.Dir, "blobs"))
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, info := range infos {
+		size += info.Size()
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+	for _, info := range infos {
+		if size <= c.MaxSize {
+			break
+		}
+		if info.Name() == keep {
+			continue
+		}
+		if err = os.Remove(c.Path(info.Name())); err != nil {
+			return err
+		}
+		size -= info.Size()
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+const (
+	cacheContent = "# TEST FILE\n"
+	cacheSum     = "d17245c4f327262bb7c4d7571a95d71d452bb6073331d7866b289154be6396ba"
+)
+
+func newCacheServer(t *testing.T, hits *int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*hits++
+		if r.URL.Path == "/missing.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, cacheContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCacheNewSource(t *testing.T) {
+	var hits int
+	srv := newCacheServer(t, &hits)
+	f := &Fetcher{
+		Cache: NewCache(t.TempDir()),
+	}
+	url := srv.URL + "/file.tar.gz"
+	for i := 0; i < 2; i++ {
+		src, err := f.NewSource(context.Background(), url, nil)
+		if err != nil {
+			t.Fatalf("expected no error, found: %s", err)
+		}
+		if hash := src.Digest.Value; hash != cacheSum {
+			t.Fatalf("expected '%s', found: %s", cacheSum, hash)
+		}
+	}
+	if hits != 1 {
+		t.Fatalf("expected 1 download, found: %d", hits)
+	}
+	if _, err := os.Stat(f.Cache.Path(cacheSum)); err != nil {
+		t.Fatalf("expected cached contents, found: %s", err)
+	}
+}
+
+func TestCacheMaxSize(t *testing.T) {
+	var hits int
+	srv := newCacheServer(t, &hits)
+	f := &Fetcher{
+		MaxSize: int64(len(cacheContent) + 1),
+		Cache:   NewCache(t.TempDir()),
+	}
+	src, err := f.NewSource(context.Background(), srv.URL+"/file.tar.gz", nil)
+	if err != nil {
+		t.Fatalf("expected no error, found: %s", err)
+	}
+	if hash := src.Digest.Value; hash != cacheSum {
+		t.Fatalf("expected '%s', found: %s", cacheSum, hash)
+	}
+}
+
+func TestCacheOffline(t *testing.T) {
+	var hits int
+	srv := newCacheServer(t, &hits)
+	f := &Fetcher{
+		Cache: NewCache(t.TempDir()),
+	}
+	url := srv.URL + "/file.tar.gz"
+	if _, err := f.Cache.Store(url, strings.NewReader(cacheContent)); err != nil {
+		t.Fatalf("expected no error, found: %s", err)
+	}
+	f.Cache.Offline = true
+	src, err := f.NewSource(context.Background(), url, nil)
+	if err != nil {
+		t.Fatalf("expected no error, found: %s", err)
+	}
+	if hash := src.Digest.Value; hash != cacheSum {
+		t.Fatalf("expected '%s', found: %s", cacheSum, hash)
+	}
+	if _, err = f.NewSource(context.Background(), srv.URL+"/other.tar.gz", nil); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("expected ErrCacheMiss, found: %v", err)
+	}
+	if hits != 0 {
+		t.Fatalf("expected no downloads, found: %d", hits)
+	}
+}
+
+func TestCacheNotFound(t *testing.T) {
+	var hits int
+	srv := newCacheServer(t, &hits)
+	f := &Fetcher{
+		Cache: NewCache(t.TempDir()),
+	}
+	url := srv.URL + "/missing.tar.gz"
+	if _, err := f.NewSource(context.Background(), url, nil); err == nil {
+		t.Fatal("expected error for missing source")
+	}
+	if _, ok := f.Cache.Lookup(url); ok {
+		t.Fatal("missing source should not be cached")
+	}
+}
+
+func TestCacheEvict(t *testing.T) {
+	c := NewCache(t.TempDir())
+	c.MaxSize = int64(len(cacheContent) + 1)
+	first, err := c.Store("https://example.com/first", strings.NewReader(cacheContent))
+	if err != nil {
+		t.Fatalf("expected no error, found: %s", err)
+	}
+	second, err := c.Store("https://example.com/second", strings.NewReader("# OTHER FILE\n"))
+	if err != nil {
+		t.Fatalf("expected no error, found: %s", err)
+	}
+	if _, ok := c.Lookup("https://example.com/first"); ok {
+		t.Errorf("expected '%s' to be evicted", first)
+	}
+	if hash, ok := c.Lookup("https://example.com/second"); !ok || hash != second {
+		t.Errorf("expected '%s', found: %s", second, hash)
+	}
+}
+
+func TestCacheEvictOversized(t *testing.T) {
+	c := NewCache(t.TempDir())
+	c.MaxSize = int64(len(cacheContent) - 1)
+	hash, err := c.Store("https://example.com/first", strings.NewReader(cacheContent))
+	if err != nil {
+		t.Fatalf("expected no error, found: %s", err)
+	}
+	f, err := c.Open(hash)
+	if err != nil {
+		t.Fatalf("expected no error, found: %s", err)
+	}
+	f.Close()
+	if found, ok := c.Lookup("https://example.com/first"); !ok || found != hash {
+		t.Errorf("expected '%s', found: %s", hash, found)
+	}
+}
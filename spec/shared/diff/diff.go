@@ -0,0 +1,181 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is the kind of change made to a single line
+type Op byte
+
+const (
+	// Equal means the line is in both inputs
+	Equal Op = ' '
+	// Delete means the line is only in the first input
+	Delete Op = '-'
+	// Insert means the line is only in the second input
+	Insert Op = '+'
+)
+
+// Edit is a single line of a diff
+type Edit struct {
+	Op   Op
+	Text string
+}
+
+// String gets the line as it appears in a unified diff
+func (e Edit) String() string {
+	return string(e.Op) + e.Text
+}
+
+// Lines finds the shortest list of Edits turning "a" into "b"
+//
+// This uses the longest common subsequence, which is fine for files the size of a package.yml.
+func Lines(a, b []string) (edits []Edit) {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, Edit{Equal, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, Edit{Delete, a[i]})
+			i++
+		default:
+			edits = append(edits, Edit{Insert, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, Edit{Delete, a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, Edit{Insert, b[j]})
+	}
+	return
+}
+
+// Hunk is a group of nearby Edits, with some unchanged lines around them for context
+type Hunk struct {
+	// AStart and BStart are the first line numbers of the Hunk in each input, starting at 1
+	AStart, BStart int
+	// ALines and BLines are the number of lines of the Hunk from each input
+	ALines, BLines int
+	Edits          []Edit
+}
+
+// String gets the Hunk in unified diff format
+func (h Hunk) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", h.AStart, h.ALines, h.BStart, h.BLines)
+	for _, e := range h.Edits {
+		b.WriteString(e.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Hunks groups Edits into Hunks, keeping up to "context" unchanged lines around each change
+//
+// Changes separated by no more than twice the context share a single Hunk.
+func Hunks(edits []Edit, context int) (hunks []Hunk) {
+	var changes []int
+	for i, e := range edits {
+		if e.Op != Equal {
+			changes = append(changes, i)
+		}
+	}
+	for len(changes) > 0 {
+		first, last := changes[0], changes[0]
+		for len(changes) > 0 && changes[0]-last <= 2*context {
+			last = changes[0]
+			changes = changes[1:]
+		}
+		start, end := first-context, last+context+1
+		if start < 0 {
+			start = 0
+		}
+		if end > len(edits) {
+			end = len(edits)
+		}
+		h := Hunk{
+			AStart: 1,
+			BStart: 1,
+		}
+		for _, e := range edits[:start] {
+			if e.Op != Insert {
+				h.AStart++
+			}
+			if e.Op != Delete {
+				h.BStart++
+			}
+		}
+		for _, e := range edits[start:end] {
+			h.Edits = append(h.Edits, e)
+			if e.Op != Insert {
+				h.ALines++
+			}
+			if e.Op != Delete {
+				h.BLines++
+			}
+		}
+		hunks = append(hunks, h)
+	}
+	return
+}
+
+// Unified gets a unified diff between two texts, or an empty string if they are the same
+func Unified(aName, bName, a, b string, context int) string {
+	if a == b {
+		return ""
+	}
+	edits := Lines(split(a), split(b))
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+	for _, h := range Hunks(edits, context) {
+		out.WriteString(h.String())
+	}
+	return out.String()
+}
+
+// split breaks a text into lines, without a trailing empty line
+func split(text string) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
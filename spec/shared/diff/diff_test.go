@@ -0,0 +1,109 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package diff
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	a := []string{"one", "two", "three"}
+	b := []string{"one", "three", "four"}
+	expected := []Edit{
+		{Equal, "one"},
+		{Delete, "two"},
+		{Equal, "three"},
+		{Insert, "four"},
+	}
+	if edits := Lines(a, b); !reflect.DeepEqual(edits, expected) {
+		t.Fatalf("expected %v, found: %v", expected, edits)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	var a, b []string
+	for _, c := range "abcdefghijklmnop" {
+		a = append(a, string(c))
+	}
+	b = append(b, a...)
+	b[1] = "B"
+	b[14] = "O"
+	expected := `--- a
++++ b
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -12,5 +12,5 @@
+ l
+ m
+ n
+-o
++O
+ p
+`
+	result := Unified("a", "b", strings.Join(a, "\n")+"\n", strings.Join(b, "\n")+"\n", 3)
+	if result != expected {
+		t.Fatalf("expected:\n%s\nfound:\n%s", expected, result)
+	}
+	if result = Unified("a", "b", "same\n", "same\n", 3); len(result) != 0 {
+		t.Fatalf("expected no diff, found:\n%s", result)
+	}
+}
+
+func TestMerge3(t *testing.T) {
+	base := []string{"one", "two", "three", "four", "five"}
+	ours := []string{"one", "TWO", "three", "four", "five"}
+	theirs := []string{"one", "two", "three", "four", "FIVE", "six"}
+	expected := []string{"one", "TWO", "three", "four", "FIVE", "six"}
+	out, conflict := Merge3(base, ours, theirs)
+	if conflict {
+		t.Fatal("expected no conflict")
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("expected %v, found: %v", expected, out)
+	}
+}
+
+func TestMerge3Conflict(t *testing.T) {
+	base := []string{"one", "two", "three"}
+	ours := []string{"one", "TWO", "three"}
+	theirs := []string{"one", "Two", "three"}
+	expected := []string{"one", ConflictOurs, "TWO", ConflictSeparator, "Two", ConflictTheirs, "three"}
+	out, conflict := Merge3(base, ours, theirs)
+	if !conflict {
+		t.Fatal("expected a conflict")
+	}
+	if !reflect.DeepEqual(out, expected) {
+		t.Fatalf("expected %v, found: %v", expected, out)
+	}
+}
+
+func TestMergeText(t *testing.T) {
+	out, conflict := MergeText("a\nb\n", "a\nb\nc\n", "z\na\nb\n")
+	if conflict || out != "z\na\nb\nc\n" {
+		t.Fatalf("expected a clean merge, found: %t\n%s", conflict, out)
+	}
+	if out, _ = MergeText("", "", ""); len(out) != 0 {
+		t.Fatalf("expected an empty result, found: %q", out)
+	}
+}
@@ -0,0 +1,114 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package diff
+
+import (
+	"strings"
+)
+
+const (
+	// ConflictOurs starts the lines from "ours" in a conflict
+	ConflictOurs = "<<<<<<< ours"
+	// ConflictSeparator separates the two sides of a conflict
+	ConflictSeparator = "======="
+	// ConflictTheirs ends the lines from "theirs" in a conflict
+	ConflictTheirs = ">>>>>>> theirs"
+)
+
+// matches maps each line of "a" to the line of "b" it is kept as, or -1 if it was removed
+func matches(a, b []string) []int {
+	match := make([]int, len(a))
+	i, j := 0, 0
+	for _, e := range Lines(a, b) {
+		switch e.Op {
+		case Equal:
+			match[i] = j
+			i++
+			j++
+		case Delete:
+			match[i] = -1
+			i++
+		case Insert:
+			j++
+		}
+	}
+	return match
+}
+
+// equal checks if two lists of lines are the same
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge3 combines the changes made to "base" by "ours" and "theirs", like diff3
+//
+// Changes to different lines are both kept. Where both sides change the same lines
+// differently, both versions are kept between git-style conflict markers and
+// "conflict" is set.
+func Merge3(base, ours, theirs []string) (out []string, conflict bool) {
+	matchOurs, matchTheirs := matches(base, ours), matches(base, theirs)
+	i, io, it := 0, 0, 0
+	for i < len(base) || io < len(ours) || it < len(theirs) {
+		// Find the next base line kept by both sides
+		k := i
+		for k < len(base) && (matchOurs[k] < 0 || matchTheirs[k] < 0) {
+			k++
+		}
+		ko, kt := len(ours), len(theirs)
+		if k < len(base) {
+			ko, kt = matchOurs[k], matchTheirs[k]
+		}
+		if k == i && ko == io && kt == it {
+			out = append(out, base[i])
+			i, io, it = i+1, io+1, it+1
+			continue
+		}
+		b, o, t := base[i:k], ours[io:ko], theirs[it:kt]
+		switch {
+		case equal(o, b):
+			out = append(out, t...)
+		case equal(t, b), equal(o, t):
+			out = append(out, o...)
+		default:
+			conflict = true
+			out = append(out, ConflictOurs)
+			out = append(out, o...)
+			out = append(out, ConflictSeparator)
+			out = append(out, t...)
+			out = append(out, ConflictTheirs)
+		}
+		i, io, it = k, ko, kt
+	}
+	return
+}
+
+// MergeText combines the changes made to a multi-line text by two sides, like Merge3
+func MergeText(base, ours, theirs string) (out string, conflict bool) {
+	lines, conflict := Merge3(split(base), split(ours), split(theirs))
+	if len(lines) > 0 {
+		out = strings.Join(lines, "\n") + "\n"
+	}
+	return
+}
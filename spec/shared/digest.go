@@ -0,0 +1,109 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/blake2b"
+	"hash"
+	"io"
+	"strings"
+)
+
+// Algorithm is the name of a supported hash function for source Digests
+type Algorithm string
+
+const (
+	// SHA256 is the default Algorithm, written without a prefix in YAML
+	SHA256 Algorithm = "sha256"
+	// SHA512 is the SHA-512 Algorithm
+	SHA512 Algorithm = "sha512"
+	// BLAKE2b is the 512-bit BLAKE2b Algorithm
+	BLAKE2b Algorithm = "blake2b"
+)
+
+// ErrUnknownAlgorithm indicates a Digest with an unsupported hash function
+var ErrUnknownAlgorithm = errors.New("unsupported hash algorithm")
+
+// New creates an empty hash for this Algorithm
+func (a Algorithm) New() (h hash.Hash, err error) {
+	switch a {
+	case SHA256, "":
+		h = crypto.SHA256.New()
+	case SHA512:
+		h = crypto.SHA512.New()
+	case BLAKE2b:
+		h, err = blake2b.New512(nil)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnknownAlgorithm, a)
+	}
+	return
+}
+
+// Sum reads all of "in" and gets its Digest for this Algorithm
+func (a Algorithm) Sum(in io.Reader) (d Digest, err error) {
+	h, err := a.New()
+	if err != nil {
+		return
+	}
+	if _, err = io.Copy(h, in); err != nil {
+		return
+	}
+	d = Digest{
+		Algorithm: a,
+		Value:     fmt.Sprintf("%x", h.Sum(nil)),
+	}
+	if len(a) == 0 {
+		d.Algorithm = SHA256
+	}
+	return
+}
+
+// Digest is the hash of the contents of a source, tagged with the Algorithm used
+//
+// In YAML, SHA256 digests are written as a bare hex string and all others are
+// prefixed by their Algorithm, like "sha512:<hex>"
+type Digest struct {
+	Algorithm Algorithm
+	Value     string
+}
+
+// ParseDigest reads a Digest from its string form
+func ParseDigest(s string) (d Digest) {
+	d.Algorithm = SHA256
+	d.Value = s
+	if i := strings.Index(s, ":"); i > 0 {
+		d.Algorithm = Algorithm(strings.ToLower(s[:i]))
+		d.Value = s[i+1:]
+	}
+	return
+}
+
+// IsEmpty checks if there is no Value for this Digest
+func (d Digest) IsEmpty() bool {
+	return len(d.Value) == 0
+}
+
+// String gets the form of this Digest used in YAML
+func (d Digest) String() string {
+	if d.Algorithm == SHA256 || len(d.Algorithm) == 0 || d.IsEmpty() {
+		return d.Value
+	}
+	return string(d.Algorithm) + ":" + d.Value
+}
@@ -0,0 +1,76 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBLAKE2b(t *testing.T) {
+	sums := map[string]string{
+		"":                       "786a02f742015903c6c6fd852552d272912f4740e15847618a86e217f71f5419d25e1031afee585313896444934eb04b903a685b1448b755d56f701afe9be2ce",
+		"abc":                    "ba80a53f981c4d0d6a2797b69f12f6e94c212f14685ac4b74b12bb6fdbffa2d17d87c5392aab792dc252d5de4533cc9518d38aa8dbf1925ab92386edd4009923",
+		strings.Repeat("a", 128): "fc6c71f688f43ea7d60817478808f3cac753e61571865c95adbc2d9122c943a76b92c2cb1047ef3fe7bf6e436ec1d0a99a9e5b216780bf7fed9d7ca91d3a8f3b",
+		strings.Repeat("a", 129): "55e6e0eb418149a8af92fd9ddc99254781b2f522a131b4f4d984404b71a00e1167b8124d5dcddd4c6977b299392335d6edd303da6d344d74bbef2d38101b232b",
+	}
+	for input, sum := range sums {
+		d, err := BLAKE2b.Sum(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Expected no error, found: %s", err)
+		}
+		if d.Value != sum {
+			t.Errorf("expected '%s' for %d bytes, found: %s", sum, len(input), d.Value)
+		}
+	}
+}
+
+func TestParseDigest(t *testing.T) {
+	d := ParseDigest("97bb4ca8003fcf36075a968bd6bf80f864acac5d26284fb92e3fe6899ad92fd5")
+	if d.Algorithm != SHA256 {
+		t.Errorf("expected '%s', found: %s", SHA256, d.Algorithm)
+	}
+	d = ParseDigest("SHA512:cf83e135")
+	if d.Algorithm != SHA512 || d.Value != "cf83e135" {
+		t.Errorf("expected '%s:%s', found: %s:%s", SHA512, "cf83e135", d.Algorithm, d.Value)
+	}
+	if s := d.String(); s != "sha512:cf83e135" {
+		t.Errorf("expected '%s', found: %s", "sha512:cf83e135", s)
+	}
+	if _, err := Algorithm("md5").New(); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("expected ErrUnknownAlgorithm, found: %v", err)
+	}
+}
+
+func TestNewSourceAlgorithm(t *testing.T) {
+	wd, _ := os.Getwd()
+	file := "file://" + wd + "/TESTING/file.md"
+	f := &Fetcher{
+		Algorithm: SHA512,
+	}
+	src, err := f.NewSource(context.Background(), file, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	sum := "sha512:4d28d55c62df7659b74cdd69c165aa9a888f2451c5e6bd7834be8cb8df77411bb0385f0a57f8409fd67d0cd08e6b6df195cd2507f4c9232f91ef2bfd8e7e598f"
+	if v := src.Value(); v != sum {
+		t.Fatalf("expected '%s', found: %s", sum, v)
+	}
+}
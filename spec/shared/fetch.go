@@ -0,0 +1,146 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+var (
+	// ErrTooLarge indicates that a download is larger than the maximum allowed by a Fetcher
+	ErrTooLarge = errors.New("source exceeds the maximum download size")
+	// DefaultFetcher is used by NewSource and UpdateSources
+	DefaultFetcher = &Fetcher{
+		UserAgent: "libypkg",
+		Retries:   2,
+		Backoff:   time.Second,
+	}
+)
+
+// Fetcher configures how the contents of sources are retrieved
+type Fetcher struct {
+	// Client is used for HTTP(S) requests, ignored if nil
+	Client *http.Client
+	// Transport is used for HTTP(S) requests if there is no Client, http.DefaultTransport if nil
+	Transport http.RoundTripper
+	// UserAgent is sent with every HTTP(S) request, if set
+	UserAgent string
+	// Retries is the number of extra attempts made for an HTTP(S) request that fails
+	Retries int
+	// Backoff is the delay before the first retry, doubling after each retry
+	Backoff time.Duration
+	// MaxSize is the largest allowed download in bytes, unlimited if zero
+	MaxSize int64
+	// Cache stores downloads, caching is disabled if nil
+	Cache *Cache
+	// Algorithm is the hash function used for new sources, SHA256 if empty
+	Algorithm Algorithm
+	// Keyring is a file of trusted keys for checking detached signatures, signatures are not checked if empty
+	Keyring string
+	// ResolveRefs pins the references of Git sources to the full hash of their commit
+	ResolveRefs bool
+	// GitMirrors maps the URL of a Git source to a local bare mirror, used instead of "git ls-remote"
+	GitMirrors map[string]string
+}
+
+// client gets the HTTP client for this Fetcher
+func (f *Fetcher) client() *http.Client {
+	if f.Client != nil {
+		return f.Client
+	}
+	if f.Transport != nil {
+		return &http.Client{
+			Transport: f.Transport,
+		}
+	}
+	return http.DefaultClient
+}
+
+// retryable checks if a failed request is worth trying again
+func retryable(r *http.Response) bool {
+	return r.StatusCode == http.StatusTooManyRequests || r.StatusCode >= http.StatusInternalServerError
+}
+
+// get requests the contents of a URL, retrying with backoff on failure
+func (f *Fetcher) get(ctx context.Context, URL string) (r *http.Response, err error) {
+	delay := f.Backoff
+	for attempt := 0; ; attempt++ {
+		var req *http.Request
+		if req, err = http.NewRequestWithContext(ctx, http.MethodGet, URL, nil); err != nil {
+			return
+		}
+		if len(f.UserAgent) > 0 {
+			req.Header.Set("User-Agent", f.UserAgent)
+		}
+		if r, err = f.client().Do(req); err == nil {
+			if r.StatusCode == http.StatusOK {
+				break
+			}
+			r.Body.Close()
+			err = fmt.Errorf("failed to download '%s': %s", URL, r.Status)
+			retry := retryable(r)
+			r = nil
+			if !retry {
+				return
+			}
+		}
+		if ctx.Err() != nil || attempt >= f.Retries {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	if f.MaxSize > 0 && r.ContentLength > f.MaxSize {
+		r.Body.Close()
+		r = nil
+		err = fmt.Errorf("%w: %s", ErrTooLarge, URL)
+	}
+	return
+}
+
+// cached gets the location of the contents of a URL in the Cache, downloading it if missing
+func (f *Fetcher) cached(ctx context.Context, URL string, in *progressReader) (path string, err error) {
+	hash, ok := f.Cache.Lookup(URL)
+	if !ok {
+		if f.Cache.Offline {
+			err = fmt.Errorf("%w: %s", ErrCacheMiss, URL)
+			return
+		}
+		var r *http.Response
+		if r, err = f.get(ctx, URL); err != nil {
+			return
+		}
+		defer r.Body.Close()
+		in.in = r.Body
+		in.total = r.ContentLength
+		in.limit = f.MaxSize
+		if hash, err = f.Cache.Store(URL, in); err != nil {
+			return
+		}
+	}
+	path = f.Cache.Path(hash)
+	return
+}
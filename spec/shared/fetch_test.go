@@ -0,0 +1,130 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// roundTripper serves every request from a function instead of the network
+type roundTripper func(r *http.Request) (*http.Response, error)
+
+func (rt roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	return rt(r)
+}
+
+func TestFetcherRetries(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if hits < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, cacheContent)
+	}))
+	defer srv.Close()
+	f := &Fetcher{
+		Retries: 2,
+		Backoff: time.Millisecond,
+	}
+	url := srv.URL + "/file.tar.gz"
+	src, err := f.NewSource(context.Background(), url, nil)
+	if err != nil {
+		t.Fatalf("expected no error, found: %s", err)
+	}
+	if hash := src.Digest.Value; hash != cacheSum {
+		t.Fatalf("expected '%s', found: %s", cacheSum, hash)
+	}
+	if hits != 3 {
+		t.Fatalf("expected 3 attempts, found: %d", hits)
+	}
+}
+
+func TestFetcherNoRetryNotFound(t *testing.T) {
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+	f := &Fetcher{
+		Retries: 2,
+		Backoff: time.Millisecond,
+	}
+	if _, err := f.NewSource(context.Background(), srv.URL+"/file.tar.gz", nil); err == nil {
+		t.Fatal("expected error for missing source")
+	}
+	if hits != 1 {
+		t.Fatalf("expected 1 attempt, found: %d", hits)
+	}
+}
+
+func TestFetcherMaxSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Flushing first hides the Content-Length from the client
+		if r.URL.Path == "/chunked" {
+			w.(http.Flusher).Flush()
+		}
+		_, _ = io.WriteString(w, cacheContent)
+	}))
+	defer srv.Close()
+	f := &Fetcher{
+		MaxSize: int64(len(cacheContent) - 1),
+	}
+	for _, path := range []string{"/sized", "/chunked"} {
+		if _, err := f.NewSource(context.Background(), srv.URL+path, nil); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("expected ErrTooLarge for '%s', found: %v", path, err)
+		}
+	}
+}
+
+func TestFetcherTransport(t *testing.T) {
+	var agent string
+	f := &Fetcher{
+		UserAgent: "libypkg-test",
+		Transport: roundTripper(func(r *http.Request) (*http.Response, error) {
+			agent = r.Header.Get("User-Agent")
+			return &http.Response{
+				StatusCode:    http.StatusOK,
+				Status:        "200 OK",
+				Body:          ioutil.NopCloser(strings.NewReader(cacheContent)),
+				ContentLength: int64(len(cacheContent)),
+				Request:       r,
+			}, nil
+		}),
+	}
+	url := "https://example.com/file.tar.gz"
+	src, err := f.NewSource(context.Background(), url, nil)
+	if err != nil {
+		t.Fatalf("expected no error, found: %s", err)
+	}
+	if hash := src.Digest.Value; hash != cacheSum {
+		t.Fatalf("expected '%s', found: %s", cacheSum, hash)
+	}
+	if agent != "libypkg-test" {
+		t.Fatalf("expected '%s', found: %s", "libypkg-test", agent)
+	}
+}
@@ -0,0 +1,101 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// ErrUnknownRef indicates that a Git reference does not exist in a repository
+var ErrUnknownRef = errors.New("unknown Git reference")
+
+// commitPattern matches a full Git commit hash
+var commitPattern = regexp.MustCompile("^[0-9a-f]{40}$")
+
+// IsCommit checks if a Git reference is already a full commit hash
+func IsCommit(ref string) bool {
+	return commitPattern.MatchString(ref)
+}
+
+// ResolveGitRef finds the full commit hash for a tag or branch with "git ls-remote"
+//
+// Annotated tags are resolved to the commit they point at, rather than the tag object.
+func ResolveGitRef(ctx context.Context, repo, ref string) (commit string, err error) {
+	if IsCommit(ref) {
+		commit = ref
+		return
+	}
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", repo, ref, ref+"^{}")
+	out, err := cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("failed to list references for '%s': %w", repo, err)
+		return
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		// A peeled tag always takes priority over the tag itself
+		if len(commit) == 0 || strings.HasSuffix(fields[1], "^{}") {
+			commit = fields[0]
+		}
+	}
+	if len(commit) == 0 {
+		err = fmt.Errorf("%w: '%s' in '%s'", ErrUnknownRef, ref, repo)
+	}
+	return
+}
+
+// ResolveMirrorRef finds the full commit hash for a tag or branch in a local bare mirror
+func ResolveMirrorRef(ctx context.Context, mirror, ref string) (commit string, err error) {
+	cmd := exec.CommandContext(ctx, "git", "--git-dir", mirror, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	out, err := cmd.Output()
+	if err != nil {
+		err = fmt.Errorf("%w: '%s' in '%s'", ErrUnknownRef, ref, mirror)
+		return
+	}
+	commit = strings.TrimSpace(string(out))
+	return
+}
+
+// resolveRef pins a Git source to a commit, keeping the original reference as a comment
+func (f *Fetcher) resolveRef(ctx context.Context, src *SourceURI) (err error) {
+	if IsCommit(src.Ref) {
+		return
+	}
+	var commit string
+	if mirror, ok := f.GitMirrors[src.URL]; ok {
+		commit, err = ResolveMirrorRef(ctx, mirror, src.Ref)
+	} else {
+		commit, err = ResolveGitRef(ctx, src.URL, src.Ref)
+	}
+	if err != nil {
+		return
+	}
+	src.Comment = "# " + src.Ref
+	src.Ref = commit
+	return
+}
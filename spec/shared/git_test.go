@@ -0,0 +1,83 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// gitOutput runs a git command in a repo and returns its trimmed output
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s\n%s", err, out)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func TestNewSourceResolveRef(t *testing.T) {
+	repo := newGitRepo(t)
+	gitOutput(t, repo, "-c", "user.name=test", "-c", "user.email=test@example.com", "tag", "-a", "-m", "annotated", "v1.0.2")
+	commit := gitOutput(t, repo, "rev-parse", "HEAD")
+	f := &Fetcher{
+		ResolveRefs: true,
+	}
+	for _, ref := range []string{"v1.0.1", "v1.0.2", commit} {
+		src, err := f.NewSource(context.Background(), "git|file://"+repo+":"+ref, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, found: %s", err)
+		}
+		if src.Ref != commit {
+			t.Errorf("expected '%s', found: %s", commit, src.Ref)
+		}
+		if ref != commit && src.Comment != "# "+ref {
+			t.Errorf("expected '# %s', found: %s", ref, src.Comment)
+		}
+	}
+	if _, err := f.NewSource(context.Background(), "git|file://"+repo+":v9.9.9", nil); !errors.Is(err, ErrUnknownRef) {
+		t.Fatalf("expected ErrUnknownRef, found: %v", err)
+	}
+}
+
+func TestNewSourceResolveMirror(t *testing.T) {
+	repo := newGitRepo(t)
+	commit := gitOutput(t, repo, "rev-parse", "HEAD")
+	mirror := filepath.Join(t.TempDir(), "cuppa.git")
+	gitOutput(t, repo, "clone", "-q", "--mirror", repo, mirror)
+	f := &Fetcher{
+		ResolveRefs: true,
+		GitMirrors: map[string]string{
+			"https://github.com/DataDrake/cuppa": mirror,
+		},
+	}
+	src, err := f.NewSource(context.Background(), "git|https://github.com/DataDrake/cuppa:v1.0.1", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if src.Ref != commit {
+		t.Errorf("expected '%s', found: %s", commit, src.Ref)
+	}
+	if src.Comment != "# v1.0.1" {
+		t.Errorf("expected '# v1.0.1', found: %s", src.Comment)
+	}
+}
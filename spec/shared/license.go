@@ -73,3 +73,21 @@ func (l *Licenses) UnmarshalYAML(value *yaml.Node) error {
 	}
 	return nil
 }
+
+// JSONSchema describes Licenses as a single identifier or a list of identifiers
+func (l Licenses) JSONSchema() map[string]interface{} {
+	identifier := map[string]interface{}{
+		"type":      "string",
+		"minLength": 1,
+	}
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			identifier,
+			map[string]interface{}{
+				"type":     "array",
+				"minItems": 1,
+				"items":    identifier,
+			},
+		},
+	}
+}
@@ -0,0 +1,101 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package macro
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+)
+
+// Variables are the defines which are set by the build Context rather than the Catalog
+var Variables = []string{
+	"installroot", "workdir", "PKGFILES", "PKGNAME", "version", "release",
+	"libdir", "libsuffix", "LIBSUFFIX", "PREFIX", "HOST", "ARCH", "CC", "CXX",
+	"CFLAGS", "CXXFLAGS", "LDFLAGS", "JOBS", "YJOBS",
+}
+
+// Context is a single pass of a build, which decides the value of every Variable
+type Context struct {
+	// Name is the name of the package
+	Name string
+	// Version is the version of the package
+	Version string
+	// Release is the release number of the package
+	Release uint
+	// WorkDir is the directory of the extracted sources
+	WorkDir string
+	// InstallRoot is the directory the package is installed into
+	InstallRoot string
+	// PkgFiles is the "files" directory next to the package.yml
+	PkgFiles string
+	// Jobs is the number of parallel jobs, or the number of CPUs if zero
+	Jobs int
+	// Emul32 builds 32-bit libraries
+	Emul32 bool
+	// AVX2 builds libraries optimized for AVX2 capable processors
+	AVX2 bool
+	// Clang uses the clang toolchain rather than gcc
+	Clang bool
+	// CFLAGS are the flags for the C compiler
+	CFLAGS string
+	// CXXFLAGS are the flags for the C++ compiler
+	CXXFLAGS string
+	// LDFLAGS are the flags for the linker
+	LDFLAGS string
+}
+
+// Values gets the value of every Variable for this Context
+func (ctx Context) Values() map[string]string {
+	jobs := ctx.Jobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	cc, cxx := "gcc", "g++"
+	if ctx.Clang {
+		cc, cxx = "clang", "clang++"
+	}
+	host, arch, suffix := "x86_64-solus-linux", "x86_64", "64"
+	switch {
+	case ctx.Emul32:
+		host, arch, suffix = "i686-pc-linux-gnu", "i686", "32"
+		cc, cxx = cc+" -m32", cxx+" -m32"
+	case ctx.AVX2:
+		suffix = "64/haswell"
+	}
+	return map[string]string{
+		"installroot": ctx.InstallRoot,
+		"workdir":     ctx.WorkDir,
+		"PKGFILES":    ctx.PkgFiles,
+		"PKGNAME":     ctx.Name,
+		"version":     ctx.Version,
+		"release":     strconv.FormatUint(uint64(ctx.Release), 10),
+		"libdir":      "/usr/lib" + suffix,
+		"libsuffix":   suffix,
+		"LIBSUFFIX":   suffix,
+		"PREFIX":      "/usr",
+		"HOST":        host,
+		"ARCH":        arch,
+		"CC":          cc,
+		"CXX":         cxx,
+		"CFLAGS":      ctx.CFLAGS,
+		"CXXFLAGS":    ctx.CXXFLAGS,
+		"LDFLAGS":     ctx.LDFLAGS,
+		"JOBS":        fmt.Sprintf("-j%d", jobs),
+		"YJOBS":       strconv.Itoa(jobs),
+	}
+}
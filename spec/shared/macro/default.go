@@ -0,0 +1,130 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package macro
+
+// defaultCatalog holds the standard macros of ypkg, in the format of its rc.yml
+const defaultCatalog = `
+actions:
+    - autogen: |
+        NOCONFIGURE="noconfigure"; export NOCONFIGURE
+        sh ./autogen.sh %CONFOPTS%
+        ./configure %CONFOPTS%
+      description: Run autogen.sh to create the configure script, then run it
+    - configure: |
+        ./configure %CONFOPTS%
+      description: Run the configure script with the standard options
+    - reconfigure: |
+        autoreconf -vfi || exit 1
+        ./configure %CONFOPTS%
+      description: Regenerate the configure script with autoreconf, then run it
+    - make: |
+        make %JOBS%
+      description: Run make with the standard number of jobs
+    - make_install: |
+        make install DESTDIR="%installroot%"
+      description: Run make install into the install root
+    - patch: |
+        patch -t -E --no-backup-if-mismatch -f
+      description: Apply a patch, failing on any rejected hunk
+    - apply_patches: |
+        if [ -f "%PKGFILES%/series" ]; then
+            grep -v '^#' "%PKGFILES%/series" | while read -r patchfile patchargs; do
+                if [ -n "$patchfile" ]; then
+                    %patch ${patchargs:--p1} -i "%PKGFILES%/$patchfile" || exit 1
+                fi
+            done || exit 1
+        fi
+      description: Apply every patch listed in the series file of the package, in order
+    - cmake: |
+        cmake -DCMAKE_C_FLAGS="${CFLAGS}" -DCMAKE_CXX_FLAGS="${CXXFLAGS}" -DCMAKE_LD_FLAGS="${LDFLAGS}" -DCMAKE_LIB_SUFFIX="%LIBSUFFIX%" -DCMAKE_BUILD_TYPE=Release -DCMAKE_INSTALL_PREFIX="%PREFIX%"
+      description: Configure a CMake project with the standard options
+    - cmake_ninja: |
+        cmake -G Ninja -B solusBuildDir -DCMAKE_C_FLAGS="${CFLAGS}" -DCMAKE_CXX_FLAGS="${CXXFLAGS}" -DCMAKE_LD_FLAGS="${LDFLAGS}" -DCMAKE_LIB_SUFFIX="%LIBSUFFIX%" -DCMAKE_BUILD_TYPE=Release -DCMAKE_INSTALL_PREFIX="%PREFIX%"
+      description: Configure a CMake project for ninja with the standard options
+    - meson_configure: |
+        CFLAGS="${CFLAGS}" CXXFLAGS="${CXXFLAGS}" LDFLAGS="${LDFLAGS}" meson --prefix %PREFIX% --buildtype=plain --libdir="lib%LIBSUFFIX%" --libexecdir="lib%LIBSUFFIX%/%PKGNAME%" --sysconfdir=/etc --localstatedir=/var solusBuildDir
+      description: Configure a meson project with the standard options
+    - ninja_build: |
+        ninja %JOBS% -C solusBuildDir
+      description: Build a meson or CMake project with ninja
+    - ninja_install: |
+        DESTDIR="%installroot%" ninja install %JOBS% -C solusBuildDir
+      description: Install a meson or CMake project into the install root
+    - ninja_check: |
+        ninja test %JOBS% -C solusBuildDir
+      description: Run the tests of a meson or CMake project
+    - python_setup: |
+        python2 setup.py build
+      description: Build a Python 2 module
+    - python_install: |
+        python2 setup.py install --root="%installroot%"
+      description: Install a Python 2 module into the install root
+    - python_compile: |
+        python2 -m compileall -q "%installroot%"
+        python2 -O -m compileall -q "%installroot%"
+      description: Byte compile the installed Python 2 modules
+    - python3_setup: |
+        python3 setup.py build
+      description: Build a Python 3 module
+    - python3_install: |
+        python3 setup.py install --root="%installroot%"
+      description: Install a Python 3 module into the install root
+    - python3_compile: |
+        python3 -m compileall -q "%installroot%"
+        python3 -O -m compileall -q "%installroot%"
+      description: Byte compile the installed Python 3 modules
+    - perl_setup: |
+        perl Makefile.PL PREFIX=%PREFIX% NO_PACKLIST=1 NO_PERLLOCAL=1 INSTALLDIRS=vendor DESTDIR="%installroot%"
+      description: Configure a Perl module
+    - perl_build: |
+        make %JOBS%
+      description: Build a Perl module
+    - perl_install: |
+        make install DESTDIR="%installroot%"
+      description: Install a Perl module into the install root
+    - qmake: |
+        qmake QMAKE_CFLAGS_RELEASE="${CFLAGS}" QMAKE_CXXFLAGS_RELEASE="${CXXFLAGS}" QMAKE_LFLAGS="${LDFLAGS}"
+      description: Configure a qmake project with the standard flags
+    - qmake4: |
+        qmake-qt4 QMAKE_CFLAGS_RELEASE="${CFLAGS}" QMAKE_CXXFLAGS_RELEASE="${CXXFLAGS}" QMAKE_LFLAGS="${LDFLAGS}"
+      description: Configure a Qt 4 qmake project with the standard flags
+    - qmake5: |
+        qmake-qt5 QMAKE_CFLAGS_RELEASE="${CFLAGS}" QMAKE_CXXFLAGS_RELEASE="${CXXFLAGS}" QMAKE_LFLAGS="${LDFLAGS}"
+      description: Configure a Qt 5 qmake project with the standard flags
+    - cargo_fetch: |
+        cargo fetch -v --locked
+      description: Download the dependencies of a Rust project
+    - cargo_build: |
+        cargo build -v -j "%YJOBS%" --frozen --release
+      description: Build a Rust project
+    - cargo_install: |
+        cargo install -v -j "%YJOBS%" --frozen --path . --root="%installroot%/usr"
+        rm -f "%installroot%/usr/.crates.toml" "%installroot%/usr/.crates2.json"
+      description: Install a Rust project into the install root
+    - cargo_test: |
+        cargo test -v -j "%YJOBS%" --frozen --release
+      description: Run the tests of a Rust project
+    - go_fetch: |
+        go mod download -x
+      description: Download the modules of a Go project
+    - install_license: |
+        install -Dm00644 -t "%installroot%/usr/share/licenses/%PKGNAME%"
+      description: Install license files for the package
+defines:
+    - CONFOPTS: |
+        --prefix=%PREFIX% --build=%HOST% --libdir=%libdir% --mandir=/usr/share/man --infodir=/usr/share/info --datadir=/usr/share/ --sysconfdir=/etc --localstatedir=/var --sharedstatedir=/var/lib --libexecdir=%libdir%/%PKGNAME%
+`
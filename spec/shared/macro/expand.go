@@ -0,0 +1,189 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package macro
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// macroPattern finds Actions like "%make" and defines like "%libdir%"
+var macroPattern = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_]*)(%?)`)
+
+// maxDepth limits how deeply macros may expand to other macros
+const maxDepth = 16
+
+// Unknown is a macro used in a script which is not known to the Catalog or Context
+type Unknown struct {
+	// Name is the macro as it was written, like "%confgure"
+	Name string
+	// Line is the line of the script where it was found, starting at 1
+	Line int
+}
+
+// String gets a message for this Unknown macro
+func (u Unknown) String() string {
+	return fmt.Sprintf("line %d: %s '%s'", u.Line, ErrUnknownMacro, u.Name)
+}
+
+// commandPosition checks if the text before a macro ends where a shell command could start
+func commandPosition(before string) bool {
+	before = strings.TrimSpace(before)
+	if len(before) == 0 || strings.ContainsAny(before[len(before)-1:], ";|&({`") {
+		return true
+	}
+	for _, keyword := range []string{"then", "do", "else", "!"} {
+		if before == keyword || strings.HasSuffix(before, " "+keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// suspicious checks if an undefined macro is probably a mistake, rather than a use of '%' by the shell
+//
+// Actions are only checked where a command starts, and defines must have names longer than
+// a single character, so that "${file%.gz}" and "printf '%s%s'" are left alone.
+func suspicious(line string, match []int) bool {
+	if match[5] > match[4] {
+		return match[3]-match[2] > 1
+	}
+	return commandPosition(line[:match[0]])
+}
+
+// expand replaces every known macro in a script, recording those which are unknown
+func (c *Catalog) expand(script string, values map[string]string, depth int) (out string, unknown []Unknown, err error) {
+	if depth > maxDepth {
+		err = ErrRecursiveMacro
+		return
+	}
+	lines := strings.Split(script, "\n")
+	for i, line := range lines {
+		var b strings.Builder
+		last := 0
+		for _, match := range macroPattern.FindAllStringSubmatchIndex(line, -1) {
+			name := line[match[2]:match[3]]
+			define := match[5] > match[4]
+			var value string
+			var ok bool
+			if define {
+				if value, ok = values[name]; !ok {
+					value, ok = c.Defines[name]
+				}
+			} else {
+				var action Action
+				action, ok = c.Actions[name]
+				value = action.Script
+			}
+			if !ok {
+				if suspicious(line, match) {
+					unknown = append(unknown, Unknown{Name: line[match[0]:match[1]], Line: i + 1})
+				}
+				continue
+			}
+			if value, _, err = c.expand(value, values, depth+1); err != nil {
+				err = fmt.Errorf("%w: '%s'", err, line[match[0]:match[1]])
+				return
+			}
+			b.WriteString(line[last:match[0]])
+			b.WriteString(value)
+			last = match[1]
+		}
+		b.WriteString(line[last:])
+		lines[i] = b.String()
+	}
+	out = strings.Join(lines, "\n")
+	return
+}
+
+// Expand replaces every macro in a script with its value for a build Context
+//
+// Unknown macros are left in place, but they are an error where a command should be.
+func (c *Catalog) Expand(script string, ctx Context) (string, error) {
+	out, unknown, err := c.expand(script, ctx.Values(), 0)
+	if err != nil {
+		return "", err
+	}
+	if len(unknown) > 0 {
+		return "", fmt.Errorf("%w: '%s' on line %d", ErrUnknownMacro, unknown[0].Name, unknown[0].Line)
+	}
+	return out, nil
+}
+
+// ExpandLines replaces every macro in a script like Expand, also finding the line each line of the result came from
+//
+// Unknown macros are left in place without an error, so that the rest of the script may still be checked.
+func (c *Catalog) ExpandLines(script string, ctx Context) (out string, lines []int, err error) {
+	values := ctx.Values()
+	expanded := strings.Split(script, "\n")
+	for i, line := range expanded {
+		if expanded[i], _, err = c.expand(line, values, 0); err != nil {
+			return
+		}
+		for n := strings.Count(expanded[i], "\n"); n >= 0; n-- {
+			lines = append(lines, i+1)
+		}
+	}
+	out = strings.Join(expanded, "\n")
+	return
+}
+
+// Unknown finds every macro in a script which is probably a mistake, like "%confgure"
+func (c *Catalog) Unknown(script string) []Unknown {
+	values := make(map[string]string)
+	for _, name := range Variables {
+		values[name] = ""
+	}
+	_, unknown, err := c.expand(script, values, 0)
+	if err != nil {
+		return nil
+	}
+	return unknown
+}
+
+// quote writes a value in single quotes for the shell, so that nothing in it is expanded
+func quote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// Script builds the final shell script for a build stage, starting with the environment of the Context
+func (c *Catalog) Script(environment, stage string, ctx Context) (string, error) {
+	values := ctx.Values()
+	var b strings.Builder
+	b.WriteString("#!/bin/bash\nset -e -x\n")
+	for _, name := range []string{"CFLAGS", "CXXFLAGS", "LDFLAGS", "CC", "CXX"} {
+		fmt.Fprintf(&b, "export %s=%s\n", name, quote(values[name]))
+	}
+	fmt.Fprintf(&b, "export workdir=%s\n", quote(values["workdir"]))
+	fmt.Fprintf(&b, "export installdir=%s\n", quote(values["installroot"]))
+	fmt.Fprintf(&b, "export pkgfiles=%s\n", quote(values["PKGFILES"]))
+	fmt.Fprintf(&b, "export version=%s\n", quote(values["version"]))
+	fmt.Fprintf(&b, "export release=%s\n", quote(values["release"]))
+	fmt.Fprintf(&b, "cd \"$workdir\"\n")
+	for _, script := range []string{environment, stage} {
+		if len(strings.TrimSpace(script)) == 0 {
+			continue
+		}
+		out, err := c.Expand(script, ctx)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(strings.TrimRight(out, "\n") + "\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,162 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package macro
+
+import (
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+var (
+	// ErrInvalidCatalog indicates a macro definition file which is not in the format of ypkg's rc.yml
+	ErrInvalidCatalog = errors.New("macros must be lists of single entry maps under 'actions' and 'defines'")
+	// ErrUnknownMacro indicates a macro which is not defined by the Catalog or the build Context
+	ErrUnknownMacro = errors.New("unknown macro")
+	// ErrRecursiveMacro indicates a macro which expands to itself
+	ErrRecursiveMacro = errors.New("macro expands to itself")
+)
+
+// Action is a macro like "%configure" which expands to one or more shell commands
+//
+// Any arguments following an Action in a script are appended to its last command.
+type Action struct {
+	// Name is the name of the Action, without the leading '%'
+	Name string
+	// Script is the shell code the Action expands to
+	Script string
+	// Description explains what the Action does
+	Description string
+}
+
+// Catalog is the set of macros which may be used in a build stage
+type Catalog struct {
+	// Actions are the macros like "%make", by name
+	Actions map[string]Action
+	// Defines are the macros like "%CONFOPTS%", by name
+	Defines map[string]string
+}
+
+// catalogFile is the layout of ypkg's rc.yml
+//
+// Each entry is a single entry map of name to value, with an optional description:
+//
+//	actions:
+//	    - make: |
+//	        make %JOBS%
+//	      description: Run make with the default number of jobs
+//	defines:
+//	    - PREFIX: /usr
+type catalogFile struct {
+	Actions []map[string]string `yaml:"actions"`
+	Defines []map[string]string `yaml:"defines"`
+}
+
+// entry splits a single entry of the catalog file into its parts
+func entry(m map[string]string) (name, value, description string, err error) {
+	description = m["description"]
+	found := 0
+	for k, v := range m {
+		if k == "description" {
+			continue
+		}
+		name, value = k, v
+		found++
+	}
+	if found != 1 || len(name) == 0 {
+		err = ErrInvalidCatalog
+	}
+	return
+}
+
+// NewCatalog creates an empty Catalog
+func NewCatalog() *Catalog {
+	return &Catalog{
+		Actions: make(map[string]Action),
+		Defines: make(map[string]string),
+	}
+}
+
+// Read parses a Catalog from the format of ypkg's rc.yml
+func Read(in io.Reader) (c *Catalog, err error) {
+	var file catalogFile
+	if err = yaml.NewDecoder(in).Decode(&file); err != nil {
+		err = fmt.Errorf("%w: %s", ErrInvalidCatalog, err)
+		return
+	}
+	c = NewCatalog()
+	for _, m := range file.Actions {
+		name, script, description, perr := entry(m)
+		if perr != nil {
+			err = perr
+			return
+		}
+		c.Actions[name] = Action{
+			Name:        name,
+			Script:      strings.TrimRight(script, "\n"),
+			Description: description,
+		}
+	}
+	for _, m := range file.Defines {
+		name, value, _, perr := entry(m)
+		if perr != nil {
+			err = perr
+			return
+		}
+		c.Defines[name] = strings.TrimRight(value, "\n")
+	}
+	return
+}
+
+// Load reads a Catalog from a macro definition file, like ypkg's rc.yml
+func Load(path string) (c *Catalog, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Default gets a Catalog with the standard macros of ypkg
+func Default() *Catalog {
+	c, err := Read(strings.NewReader(defaultCatalog))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Names lists every macro as it is written in a script, including those set by a build Context
+func (c *Catalog) Names() []string {
+	var names []string
+	for name := range c.Actions {
+		names = append(names, "%"+name)
+	}
+	for name := range c.Defines {
+		names = append(names, "%"+name+"%")
+	}
+	for _, name := range Variables {
+		names = append(names, "%"+name+"%")
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,153 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package macro
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testCatalog = `
+actions:
+    - greet: |
+        echo hello
+        echo %NAME%
+      description: Say hello
+defines:
+    - NAME: world
+`
+
+func TestRead(t *testing.T) {
+	c, err := Read(strings.NewReader(testCatalog))
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	action, ok := c.Actions["greet"]
+	if !ok {
+		t.Fatalf("expected '%s' action", "greet")
+	}
+	if action.Description != "Say hello" {
+		t.Errorf("expected '%s', found: %s", "Say hello", action.Description)
+	}
+	if action.Script != "echo hello\necho %NAME%" {
+		t.Errorf("expected '%s', found: %s", "echo hello\necho %NAME%", action.Script)
+	}
+	if value := c.Defines["NAME"]; value != "world" {
+		t.Errorf("expected '%s', found: %s", "world", value)
+	}
+}
+
+func TestReadInvalid(t *testing.T) {
+	in := "actions:\n    - one: a\n      two: b\n"
+	if _, err := Read(strings.NewReader(in)); !errors.Is(err, ErrInvalidCatalog) {
+		t.Errorf("expected '%s', found: %v", ErrInvalidCatalog, err)
+	}
+}
+
+func TestExpand(t *testing.T) {
+	catalog := Default()
+	cases := []struct {
+		script   string
+		ctx      Context
+		expected string
+	}{
+		{"%make", Context{Jobs: 4}, "make -j4"},
+		{"%patch -p1 < $pkgfiles/fix.patch", Context{}, "patch -t -E --no-backup-if-mismatch -f -p1 < $pkgfiles/fix.patch"},
+		{"install -Dm00644 foo.so %libdir%/foo.so", Context{}, "install -Dm00644 foo.so /usr/lib64/foo.so"},
+		{"install -Dm00644 foo.so %libdir%/foo.so", Context{Emul32: true}, "install -Dm00644 foo.so /usr/lib32/foo.so"},
+		{"install -Dm00644 foo.so %libdir%/foo.so", Context{AVX2: true}, "install -Dm00644 foo.so /usr/lib64/haswell/foo.so"},
+		{"echo %CC%", Context{Clang: true, Emul32: true}, "echo clang -m32"},
+		{"printf '%s%s' ${file%.gz} ${name%rc*}", Context{}, "printf '%s%s' ${file%.gz} ${name%rc*}"},
+	}
+	for _, c := range cases {
+		result, err := catalog.Expand(c.script, c.ctx)
+		if err != nil {
+			t.Fatalf("Expected no error, found: %s", err)
+		}
+		if result != c.expected {
+			t.Errorf("expected '%s', found: %s", c.expected, result)
+		}
+	}
+	configure, err := catalog.Expand("%configure --disable-static", Context{Name: "nano"})
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if !strings.HasPrefix(configure, "./configure --prefix=/usr --build=x86_64-solus-linux --libdir=/usr/lib64") ||
+		!strings.HasSuffix(configure, "--libexecdir=/usr/lib64/nano --disable-static") {
+		t.Errorf("expected the standard configure options, found: %s", configure)
+	}
+}
+
+func TestExpandUnknown(t *testing.T) {
+	c := Default()
+	if _, err := c.Expand("%confgure\n%make", Context{}); !errors.Is(err, ErrUnknownMacro) {
+		t.Errorf("expected '%s', found: %v", ErrUnknownMacro, err)
+	}
+	unknown := c.Unknown("%configure\ncd %workdir%/src && %mkae\necho %PREIFX% ${foo%bar}")
+	expected := []Unknown{{"%mkae", 2}, {"%PREIFX%", 3}}
+	if len(unknown) != len(expected) {
+		t.Fatalf("expected %v, found: %v", expected, unknown)
+	}
+	for i, u := range unknown {
+		if u != expected[i] {
+			t.Errorf("expected '%s', found: %s", expected[i], u)
+		}
+	}
+}
+
+func TestExpandRecursive(t *testing.T) {
+	c := NewCatalog()
+	c.Defines["LOOP"] = "%LOOP%"
+	if _, err := c.Expand("echo %LOOP%", Context{}); !errors.Is(err, ErrRecursiveMacro) {
+		t.Errorf("expected '%s', found: %v", ErrRecursiveMacro, err)
+	}
+}
+
+func TestScript(t *testing.T) {
+	ctx := Context{
+		WorkDir:     "/home/build/work",
+		InstallRoot: "/home/build/install",
+		CFLAGS:      "-O2",
+		LDFLAGS:     "-Wl,-rpath,'$ORIGIN' `id`",
+	}
+	script, err := Default().Script("export FOO=bar", "%make_install", ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	for _, line := range []string{"export CFLAGS='-O2'", `export LDFLAGS='-Wl,-rpath,'\''$ORIGIN'\'' ` + "`id`'", "cd \"$workdir\"", "export FOO=bar", "make install DESTDIR=\"/home/build/install\""} {
+		if !strings.Contains(script, line+"\n") {
+			t.Errorf("expected '%s' in script, found: %s", line, script)
+		}
+	}
+}
+
+func TestExpandLines(t *testing.T) {
+	out, lines, err := Default().ExpandLines("%autogen\nif true; then\n%make\nfi", Context{})
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	expected := []int{1, 1, 1, 2, 3, 4}
+	if n := strings.Count(out, "\n") + 1; n != len(expected) {
+		t.Fatalf("expected %d lines, found: %d", len(expected), n)
+	}
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("expected line %d, found: %d", expected[i], line)
+		}
+	}
+}
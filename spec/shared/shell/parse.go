@@ -0,0 +1,127 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shell
+
+import (
+	"mvdan.cc/sh/v3/syntax"
+	"strings"
+)
+
+// Word is a single word of a shell command
+type Word struct {
+	// Text is the word as it was written, including any quotes
+	Text string
+	// Quoted is set if any part of the word is quoted
+	Quoted bool
+}
+
+// IsAssignment checks if this Word sets a variable, like "CFLAGS=-O2"
+func (w Word) IsAssignment() bool {
+	i := strings.Index(w.Text, "=")
+	if i <= 0 {
+		return false
+	}
+	for j, c := range w.Text[:i] {
+		if !(c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || j > 0 && c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// Command is a single simple command of a script, like "make install"
+type Command struct {
+	// Line is the line of the script where the Command starts, starting at 1
+	Line int
+	// Words are every Word of the Command, including variable assignments but not redirections
+	Words []Word
+}
+
+// Args gets the name of the command being run followed by its arguments, without assignments
+func (c Command) Args() (args []string) {
+	start := true
+	for _, w := range c.Words {
+		if start && w.IsAssignment() {
+			continue
+		}
+		start = false
+		args = append(args, w.Text)
+	}
+	return
+}
+
+// source gets the text of a node as it was written in the script
+func source(script string, node syntax.Node) string {
+	return script[node.Pos().Offset():node.End().Offset()]
+}
+
+// quoted checks if any part of a word is quoted
+func quoted(word *syntax.Word) bool {
+	if word == nil {
+		return false
+	}
+	for _, part := range word.Parts {
+		switch part.(type) {
+		case *syntax.SglQuoted, *syntax.DblQuoted:
+			return true
+		}
+	}
+	return false
+}
+
+// command converts a node to a Command, if it is a simple command or a declaration like "export"
+func command(script string, node syntax.Node) (cmd Command, ok bool) {
+	switch n := node.(type) {
+	case *syntax.CallExpr:
+		for _, a := range n.Assigns {
+			cmd.Words = append(cmd.Words, Word{Text: source(script, a), Quoted: quoted(a.Value)})
+		}
+		for _, arg := range n.Args {
+			cmd.Words = append(cmd.Words, Word{Text: source(script, arg), Quoted: quoted(arg)})
+		}
+	case *syntax.DeclClause:
+		cmd.Words = append(cmd.Words, Word{Text: n.Variant.Value})
+		for _, a := range n.Args {
+			cmd.Words = append(cmd.Words, Word{Text: source(script, a), Quoted: quoted(a.Value)})
+		}
+	}
+	if len(cmd.Words) == 0 {
+		return
+	}
+	cmd.Line = int(node.Pos().Line())
+	ok = true
+	return
+}
+
+// Parse finds the simple Commands a bash script runs, in the order they are written
+//
+// Compound commands like "if" and "for" are not kept, but the Commands inside of them are, and so are
+// the Commands inside of substitutions like "$(pwd)". Comments and here-documents are skipped. When the
+// script has a syntax error, only the Commands before it are found; CheckSyntax reports the error itself.
+func Parse(script string) (cmds []Command) {
+	p := syntax.NewParser(syntax.Variant(syntax.LangBash))
+	_ = p.Stmts(strings.NewReader(script), func(stmt *syntax.Stmt) bool {
+		syntax.Walk(stmt, func(node syntax.Node) bool {
+			if cmd, ok := command(script, node); ok {
+				cmds = append(cmds, cmd)
+			}
+			return true
+		})
+		return true
+	})
+	return
+}
@@ -0,0 +1,133 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shell
+
+import (
+	"strings"
+	"testing"
+)
+
+const testScript = `# Build the docs too
+CFLAGS="$CFLAGS -fcommon" ./configure --prefix=/usr && make
+if [ -d "$workdir/docs" ]; then
+    cd docs; make 2> /dev/null
+fi
+cat > config.h <<EOF
+rm -rf $installdir
+EOF
+rm -rf $installdir/usr/share/doc \
+    "$installdir/usr/lib64/*.a"
+echo "# not a comment" '$HOME' $(pwd) ${name%.tar.gz}
+`
+
+func TestParse(t *testing.T) {
+	expected := []struct {
+		line int
+		args string
+	}{
+		{2, "./configure --prefix=/usr"},
+		{2, "make"},
+		{3, "[ -d \"$workdir/docs\" ]"},
+		{4, "cd docs"},
+		{4, "make"},
+		{6, "cat"},
+		{9, "rm -rf $installdir/usr/share/doc \"$installdir/usr/lib64/*.a\""},
+		{11, "echo \"# not a comment\" '$HOME' $(pwd) ${name%.tar.gz}"},
+		{11, "pwd"},
+	}
+	cmds := Parse(testScript)
+	if len(cmds) != len(expected) {
+		t.Fatalf("expected %d commands, found: %v", len(expected), cmds)
+	}
+	for i, e := range expected {
+		cmd := cmds[i]
+		if args := strings.Join(cmd.Args(), " "); args != e.args {
+			t.Errorf("expected '%s', found: %s", e.args, args)
+		}
+		if cmd.Line != e.line {
+			t.Errorf("expected '%s' on line %d, found: %d", e.args, e.line, cmd.Line)
+		}
+	}
+	rm := cmds[6].Words
+	if rm[2].Quoted || !rm[3].Quoted {
+		t.Errorf("expected only '%s' to be quoted", rm[3].Text)
+	}
+}
+
+const testCompound = `case "$ARCH" in
+    x86_64|i686) make ARCH=$ARCH ;;
+    *) echo "esac )" ;;
+esac
+VERSION="$(basename "$(dirname "$PWD")")"
+export CFLAGS="$CFLAGS -fcommon" LDFLAGS
+cat <<-'END'
+	make install
+	END
+function build() {
+    ninja -C out
+}
+`
+
+func TestParseCompound(t *testing.T) {
+	expected := []struct {
+		line int
+		args string
+	}{
+		{2, "make ARCH=$ARCH"},
+		{3, "echo \"esac )\""},
+		{5, ""},
+		{5, "basename \"$(dirname \"$PWD\")\""},
+		{5, "dirname \"$PWD\""},
+		{6, "export CFLAGS=\"$CFLAGS -fcommon\" LDFLAGS"},
+		{7, "cat"},
+		{11, "ninja -C out"},
+	}
+	cmds := Parse(testCompound)
+	if len(cmds) != len(expected) {
+		t.Fatalf("expected %d commands, found: %v", len(expected), cmds)
+	}
+	for i, e := range expected {
+		cmd := cmds[i]
+		if args := strings.Join(cmd.Args(), " "); args != e.args || cmd.Line != e.line {
+			t.Errorf("expected '%s' on line %d, found: '%s' on line %d", e.args, e.line, args, cmd.Line)
+		}
+	}
+	if version := cmds[2].Words[0]; !version.IsAssignment() || !version.Quoted {
+		t.Errorf("expected a quoted assignment, found: %v", version)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	cmds := Parse("make\nif true; then\n    make install\nfi fi\nninja\n")
+	if len(cmds) != 3 || cmds[2].Line != 3 {
+		t.Errorf("expected only the commands before the error, found: %v", cmds)
+	}
+}
+
+func TestCheckSyntax(t *testing.T) {
+	if err := CheckSyntax(testScript); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	err := CheckSyntax("./configure\nif true; then\n    make\nfi fi\n")
+	serr, ok := err.(*SyntaxError)
+	if !ok {
+		t.Fatalf("expected a syntax error, found: %v", err)
+	}
+	if serr.Line != 4 {
+		t.Errorf("expected line %d, found: %d", 4, serr.Line)
+	}
+}
@@ -0,0 +1,54 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shell
+
+import (
+	"errors"
+	"fmt"
+	"mvdan.cc/sh/v3/syntax"
+	"strings"
+)
+
+// SyntaxError is a syntax error found in a bash script
+type SyntaxError struct {
+	// Line is the line of the script with the error, starting at 1
+	Line int
+	// Message is the description of the error
+	Message string
+}
+
+// Error gets the message of this SyntaxError, with its line
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// CheckSyntax parses a bash script, without running any of it
+//
+// The first error found is returned as a *SyntaxError.
+func CheckSyntax(script string) error {
+	p := syntax.NewParser(syntax.Variant(syntax.LangBash))
+	_, err := p.Parse(strings.NewReader(script), "")
+	var perr syntax.ParseError
+	if errors.As(err, &perr) {
+		return &SyntaxError{Line: int(perr.Pos.Line()), Message: perr.Text}
+	}
+	var lerr syntax.LangError
+	if errors.As(err, &lerr) {
+		return &SyntaxError{Line: int(lerr.Pos.Line()), Message: strings.TrimPrefix(lerr.Error(), lerr.Pos.String()+": ")}
+	}
+	return err
+}
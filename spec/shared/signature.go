@@ -0,0 +1,137 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// KeyringName is the name of the file next to a package.yml which holds trusted upstream keys
+	KeyringName = "upstream.gpg"
+)
+
+var (
+	// SignatureExtensions are tried in order when looking for the detached signature of a source
+	SignatureExtensions = []string{".asc", ".sig", ".sign"}
+	// ErrNoSignature indicates that no detached signature could be found for a source
+	ErrNoSignature = errors.New("no detached signature found")
+	// ErrBadSignature indicates that a detached signature did not verify against the keyring
+	ErrBadSignature = errors.New("signature verification failed")
+	// ErrNoGPGV indicates that gpgv is not installed, so signatures cannot be checked
+	ErrNoGPGV = errors.New("gpgv is required to verify signatures")
+)
+
+// Signature is the result of successfully checking a detached OpenPGP signature
+type Signature struct {
+	// URL is the location of the detached signature
+	URL string
+	// Fingerprint is the fingerprint of the key which made the signature
+	Fingerprint string
+	// UserID is the primary user ID of the key which made the signature
+	UserID string
+}
+
+// Comment gets a YAML line comment recording this Signature for review
+func (sig Signature) Comment() string {
+	return fmt.Sprintf("# signed: %s (%s)", sig.Fingerprint, sig.UserID)
+}
+
+// VerifySignature checks a file against a detached OpenPGP signature, only trusting keys in the keyring
+//
+// Verification is done by gpgv, which must be installed. ErrBadSignature is only returned when gpgv
+// ran and rejected the signature, never when gpgv could not be run at all.
+func VerifySignature(ctx context.Context, path, signature, keyring string) (sig Signature, err error) {
+	if keyring, err = filepath.Abs(keyring); err != nil {
+		return
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "gpgv", "--status-fd", "1", "--keyring", keyring, signature, path)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		err = ErrNoGPGV
+		return
+	}
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		err = fmt.Errorf("failed to run gpgv: %w", err)
+		return
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] != "[GNUPG:]" {
+			continue
+		}
+		switch fields[1] {
+		case "GOODSIG":
+			sig.UserID = strings.Join(fields[3:], " ")
+		case "VALIDSIG":
+			sig.Fingerprint = fields[2]
+		}
+	}
+	if err != nil || len(sig.Fingerprint) == 0 {
+		err = fmt.Errorf("%w: %s", ErrBadSignature, strings.TrimSpace(stderr.String()))
+	}
+	return
+}
+
+// verifySignature finds the detached signature for a source and checks the contents at "path" against it
+func (f *Fetcher) verifySignature(ctx context.Context, src SourceURI, path string) (sig Signature, err error) {
+	tmp, err := ioutil.TempFile("", "signature-")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+	for _, ext := range SignatureExtensions {
+		URL := src.URL + ext
+		var in io.ReadCloser
+		if src.Scheme == "file" {
+			in, err = os.Open(strings.TrimPrefix(URL, "file://"))
+		} else {
+			r, gerr := f.get(ctx, URL)
+			if err = gerr; err == nil {
+				in = r.Body
+			}
+		}
+		if err != nil {
+			continue
+		}
+		_, err = io.Copy(tmp, in)
+		in.Close()
+		if err != nil {
+			return
+		}
+		if sig, err = VerifySignature(ctx, path, tmp.Name(), f.Keyring); err == nil {
+			sig.URL = URL
+		}
+		return
+	}
+	err = fmt.Errorf("%w: %s", ErrNoSignature, src.URL)
+	return
+}
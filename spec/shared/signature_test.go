@@ -0,0 +1,120 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newSignedSource creates a signed file and a keyring with the key that signed it
+func newSignedSource(t *testing.T) (dir, keyring string) {
+	for _, cmd := range []string{"gpg", "gpgv"} {
+		if _, err := exec.LookPath(cmd); err != nil {
+			t.Skipf("%s is not installed", cmd)
+		}
+	}
+	dir = t.TempDir()
+	home := t.TempDir()
+	keyring = filepath.Join(dir, KeyringName)
+	if err := ioutil.WriteFile(filepath.Join(dir, "file.md"), []byte(cacheContent), 0644); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	cmds := [][]string{
+		{"--quick-gen-key", "Test <test@example.com>", "ed25519", "sign", "never"},
+		{"--output", keyring, "--export"},
+		{"--armor", "--detach-sign", "--output", filepath.Join(dir, "file.md.asc"), filepath.Join(dir, "file.md")},
+	}
+	for _, args := range cmds {
+		cmd := exec.Command("gpg", append([]string{"--homedir", home, "--batch", "--passphrase", ""}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Expected no error, found: %s\n%s", err, out)
+		}
+	}
+	return
+}
+
+func TestNewSourceSignatureFile(t *testing.T) {
+	dir, keyring := newSignedSource(t)
+	f := &Fetcher{
+		Keyring: keyring,
+	}
+	src, err := f.NewSource(context.Background(), "file://"+filepath.Join(dir, "file.md"), nil)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if !strings.HasPrefix(src.Comment, "# signed: ") || !strings.Contains(src.Comment, "test@example.com") {
+		t.Errorf("expected signature comment, found: %s", src.Comment)
+	}
+	if src.Digest.Value != cacheSum {
+		t.Errorf("expected '%s', found: %s", cacheSum, src.Digest.Value)
+	}
+}
+
+func TestNewSourceSignatureHTTP(t *testing.T) {
+	dir, keyring := newSignedSource(t)
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer srv.Close()
+	f := &Fetcher{
+		Keyring: keyring,
+	}
+	src, err := f.NewSource(context.Background(), srv.URL+"/file.md", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if !strings.HasPrefix(src.Comment, "# signed: ") {
+		t.Errorf("expected signature comment, found: %s", src.Comment)
+	}
+}
+
+func TestNewSourceSignatureBad(t *testing.T) {
+	dir, keyring := newSignedSource(t)
+	path := filepath.Join(dir, "file.md")
+	if err := ioutil.WriteFile(path, []byte("# TAMPERED\n"), 0644); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	f := &Fetcher{
+		Keyring: keyring,
+	}
+	if _, err := f.NewSource(context.Background(), "file://"+path, nil); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("expected ErrBadSignature, found: %v", err)
+	}
+	if err := os.Remove(path + ".asc"); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if _, err := f.NewSource(context.Background(), "file://"+path, nil); !errors.Is(err, ErrNoSignature) {
+		t.Fatalf("expected ErrNoSignature, found: %v", err)
+	}
+}
+
+func TestVerifySignatureNoGPGV(t *testing.T) {
+	path := os.Getenv("PATH")
+	defer os.Setenv("PATH", path)
+	os.Setenv("PATH", t.TempDir())
+	_, err := VerifySignature(context.Background(), "file.md", "file.md.asc", KeyringName)
+	if !errors.Is(err, ErrNoGPGV) || errors.Is(err, ErrBadSignature) {
+		t.Fatalf("expected ErrNoGPGV, found: %v", err)
+	}
+}
@@ -17,73 +17,230 @@
 package shared
 
 import (
-	"crypto"
+	"context"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 )
 
-// Source is a single entry map of URI to hash or Git Reference
-type Source map[string]string
-
-// NewSource builds a new Source from a URI
-func NewSource(URI string) (src Source, err error) {
-	src = make(Source)
-	var in io.ReadCloser
-	if strings.HasPrefix(URI, "git|") {
-		// Git sources
-		pieces := strings.Split(URI, ":")
-		if len(pieces) < 3 {
-			err = fmt.Errorf("no hash in git URI, should resemble 'git|http://path/to/repo:commit hash")
-			return
+// Progress is called as the contents of a URI are read, total is -1 when the size is unknown
+//
+// Progress may be called from several goroutines at once by UpdateSources.
+type Progress func(URI string, read, total int64)
+
+// progressReader reports on the progress of an underlying Reader and stops when cancelled
+type progressReader struct {
+	ctx      context.Context
+	in       io.Reader
+	URI      string
+	read     int64
+	total    int64
+	limit    int64
+	progress Progress
+}
+
+// Read calls the underlying Read and reports the new progress
+func (pr *progressReader) Read(p []byte) (n int, err error) {
+	if err = pr.ctx.Err(); err != nil {
+		return
+	}
+	n, err = pr.in.Read(p)
+	pr.read += int64(n)
+	if pr.limit > 0 && pr.read > pr.limit {
+		err = fmt.Errorf("%w: %s", ErrTooLarge, pr.URI)
+		return
+	}
+	if pr.progress != nil && n > 0 {
+		pr.progress(pr.URI, pr.read, pr.total)
+	}
+	return
+}
+
+// SourceError records a failure to build the Source for a URI
+type SourceError struct {
+	URI string
+	Err error
+}
+
+// Error gets the error message, prefixed by the URI
+func (se *SourceError) Error() string {
+	return fmt.Sprintf("%s: %s", se.URI, se.Err)
+}
+
+// Unwrap gets the underlying error
+func (se *SourceError) Unwrap() error {
+	return se.Err
+}
+
+// SourceErrors is a list of failures for one or more URIs, in the order they were requested
+type SourceErrors []*SourceError
+
+// Error gets the error messages for every URI, one per line
+func (ses SourceErrors) Error() string {
+	msgs := make([]string, len(ses))
+	for i, se := range ses {
+		msgs[i] = se.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// NewSource builds a new Source from a URI, using the DefaultFetcher
+func NewSource(URI string) (src SourceURI, err error) {
+	return DefaultFetcher.NewSource(context.Background(), URI, nil)
+}
+
+// NewSourceContext builds a new Source from a URI using the DefaultFetcher, reporting progress and stopping if cancelled
+func NewSourceContext(ctx context.Context, URI string, progress Progress) (src SourceURI, err error) {
+	return DefaultFetcher.NewSource(ctx, URI, progress)
+}
+
+// NewSource builds a new Source from a URI, reporting progress and stopping if cancelled
+//
+// If the Fetcher has a Keyring, the contents are also checked against a detached
+// signature and the result is recorded as the line comment of the Source. Likewise,
+// if the Fetcher resolves references, Git sources are pinned to a commit and the
+// original reference is kept as the line comment.
+func (f *Fetcher) NewSource(ctx context.Context, URI string, progress Progress) (src SourceURI, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if src, err = ParseSourceURI(URI); err != nil {
+		return
+	}
+	if src.IsGit() {
+		if f.ResolveRefs {
+			err = f.resolveRef(ctx, &src)
 		}
-		src[strings.Join(pieces[:len(pieces)-1], ":")] = pieces[len(pieces)-1]
 		return
-	} else if strings.HasPrefix(URI, "file://") {
+	}
+	in := &progressReader{
+		ctx:      ctx,
+		URI:      URI,
+		total:    -1,
+		progress: progress,
+	}
+	// path is set when the contents are already on disk
+	var path string
+	switch {
+	case src.Scheme == "file":
 		// Local File sources
-		in, err = os.Open(strings.TrimPrefix(URI, "file://"))
-		if err != nil {
+		path = strings.TrimPrefix(src.URL, "file://")
+	case f.Cache != nil:
+		// HTTP Sources, via the cache
+		if path, err = f.cached(ctx, src.URL, in); err != nil {
 			return
 		}
-		defer in.Close()
-	} else if strings.HasPrefix(URI, "http") {
+		// Progress and MaxSize were already handled by the download
+		in.progress = nil
+		in.read = 0
+		in.limit = 0
+	default:
 		// HTTP Sources
 		var r *http.Response
-		r, err = http.Get(URI)
-		if err != nil {
+		if r, err = f.get(ctx, src.URL); err != nil {
 			return
 		}
-		in = r.Body
 		defer r.Body.Close()
-	} else {
-		err = fmt.Errorf("unsupported source type")
-		return
+		in.in = r.Body
+		in.total = r.ContentLength
+		in.limit = f.MaxSize
+	}
+	if len(path) > 0 {
+		var file *os.File
+		if file, err = os.Open(path); err != nil {
+			return
+		}
+		defer file.Close()
+		if info, serr := file.Stat(); serr == nil {
+			in.total = info.Size()
+		}
+		in.in = file
 	}
-	// All hashed are SHA256 hashes
-	hash := crypto.SHA256.New()
-	_, err = io.Copy(hash, in)
-	if err != nil {
+	var r io.Reader = in
+	if len(f.Keyring) > 0 && len(path) == 0 {
+		// Signatures can only be checked against contents on disk
+		var tmp *os.File
+		if tmp, err = ioutil.TempFile("", "source-"); err != nil {
+			return
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		r = io.TeeReader(in, tmp)
+		path = tmp.Name()
+	}
+	if src.Digest, err = f.Algorithm.Sum(r); err != nil {
 		return
 	}
-	src[URI] = fmt.Sprintf("%x", hash.Sum(nil))
+	if len(f.Keyring) > 0 {
+		var sig Signature
+		if sig, err = f.verifySignature(ctx, src, path); err != nil {
+			return
+		}
+		src.Comment = sig.Comment()
+	}
 	return
 }
 
-// UpdateSources gets the hashes for one or more URI sources
-func UpdateSources(URIs []string) (srcs []Source, err error) {
-	// for each URI
-	var src Source
-	for _, URI := range URIs {
-		// - URI : HASH
-		// Get Hash for URI
-		src, err = NewSource(URI)
-		if err != nil {
-			return
+// UpdateSources gets the hashes for one or more URI sources, using the DefaultFetcher
+func UpdateSources(URIs []string) (srcs []SourceURI, err error) {
+	return DefaultFetcher.UpdateSources(context.Background(), URIs, 1, nil)
+}
+
+// UpdateSourcesContext gets the hashes for one or more URI sources using the DefaultFetcher, with up to "workers" concurrent downloads
+func UpdateSourcesContext(ctx context.Context, URIs []string, workers int, progress Progress) (srcs []SourceURI, err error) {
+	return DefaultFetcher.UpdateSources(ctx, URIs, workers, progress)
+}
+
+// UpdateSources gets the hashes for one or more URI sources, using up to "workers" concurrent downloads
+//
+// Sources are returned in the same order as their URIs. When any of the URIs fail, the
+// remaining URIs are still processed, the failed Sources are left empty, and a SourceErrors
+// is returned with an entry for each failure.
+func (f *Fetcher) UpdateSources(ctx context.Context, URIs []string, workers int, progress Progress) (srcs []SourceURI, err error) {
+	srcs = make([]SourceURI, len(URIs))
+	errs := make([]error, len(URIs))
+	parallel(len(URIs), workers, func(i int) {
+		srcs[i], errs[i] = f.NewSource(ctx, URIs[i], progress)
+	})
+	var ses SourceErrors
+	for i, e := range errs {
+		if e != nil {
+			srcs[i] = SourceURI{}
+			ses = append(ses, &SourceError{
+				URI: URIs[i],
+				Err: e,
+			})
 		}
-		// Add source to all sources
-		srcs = append(srcs, src)
+	}
+	if len(ses) > 0 {
+		err = ses
 	}
 	return
 }
+
+// parallel calls fn for every index up to n, using up to "workers" goroutines at once
+func parallel(n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				fn(j)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
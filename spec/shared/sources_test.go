@@ -17,8 +17,14 @@
 package shared
 
 import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -35,12 +41,11 @@ func TestNewSourceGit(t *testing.T) {
 	if err != nil {
 		t.Errorf("expected no error, found: %s", err)
 	}
-	hash, ok := src["git|https://github.com/DataDrake/cuppa"]
-	if !ok {
-		t.Fatal("missing source")
+	if src.Key() != "git|https://github.com/DataDrake/cuppa" {
+		t.Fatalf("expected '%s', found: %s", "git|https://github.com/DataDrake/cuppa", src.Key())
 	}
-	if hash != "v1.0.1" {
-		t.Fatalf("expected '%s', found: %s", "v1.0.1", hash)
+	if src.Ref != "v1.0.1" {
+		t.Fatalf("expected '%s', found: %s", "v1.0.1", src.Ref)
 	}
 }
 
@@ -52,28 +57,28 @@ func TestNewSourceFile(t *testing.T) {
 	if err != nil {
 		t.Errorf("expected no error, found: %s", err)
 	}
-	hash, ok := src[file]
-	if !ok {
-		t.Fatal("missing source")
+	if src.Key() != file {
+		t.Fatalf("expected '%s', found: %s", file, src.Key())
 	}
-	if hash != sum {
-		t.Fatalf("expected '%s', found: %s", sum, hash)
+	if src.Digest.Value != sum {
+		t.Fatalf("expected '%s', found: %s", sum, src.Digest.Value)
 	}
 }
 
 func TestNewSourceHTTP(t *testing.T) {
-	url := "https://github.com/DataDrake/cuppa/archive/v1.0.1.tar.gz"
-	sum := "97bb4ca8003fcf36075a968bd6bf80f864acac5d26284fb92e3fe6899ad92fd5"
+	srv := httptest.NewServer(http.FileServer(http.Dir("TESTING")))
+	defer srv.Close()
+	url := srv.URL + "/file.md"
+	sum := "d17245c4f327262bb7c4d7571a95d71d452bb6073331d7866b289154be6396ba"
 	src, err := NewSource(url)
 	if err != nil {
 		t.Errorf("expected no error, found: %s", err)
 	}
-	hash, ok := src[url]
-	if !ok {
-		t.Fatal("missing source")
+	if src.Key() != url {
+		t.Fatalf("expected '%s', found: %s", url, src.Key())
 	}
-	if hash != sum {
-		t.Fatalf("expected '%s', found: %s", sum, hash)
+	if src.Digest.Value != sum {
+		t.Fatalf("expected '%s', found: %s", sum, src.Digest.Value)
 	}
 }
 
@@ -98,13 +103,84 @@ func TestUpdateSources(t *testing.T) {
 		t.Errorf("expected no error, found: %s", err)
 	}
 	for i, src := range srcs {
-		hash, ok := src[keys[i]]
-		if !ok {
-			t.Error("missing source")
+		if key := src.Key(); key != keys[i] {
+			t.Errorf("expected '%s', found: %s", keys[i], key)
 			continue
 		}
-		if sum := hashes[i]; hash != sum {
-			t.Errorf("expected '%s', found: %s", sum, hash)
+		if sum := hashes[i]; src.Ref != sum {
+			t.Errorf("expected '%s', found: %s", sum, src.Ref)
+		}
+	}
+}
+
+func TestUpdateSourcesContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, r.URL.Path)
+	}))
+	defer srv.Close()
+	urls := []string{
+		srv.URL + "/one.tar.gz",
+		srv.URL + "/missing.tar.gz",
+		"git|https://github.com/DataDrake/cuppa:v1.0.1",
+		srv.URL + "/three.tar.gz",
+	}
+	var lock sync.Mutex
+	read := make(map[string]int64)
+	progress := func(URI string, n, total int64) {
+		lock.Lock()
+		read[URI] = n
+		lock.Unlock()
+	}
+	srcs, err := UpdateSourcesContext(context.Background(), urls, 3, progress)
+	ses, ok := err.(SourceErrors)
+	if !ok {
+		t.Fatalf("expected SourceErrors, found: %v", err)
+	}
+	if len(ses) != 1 || ses[0].URI != urls[1] {
+		t.Fatalf("expected one error for '%s', found: %s", urls[1], ses)
+	}
+	if len(srcs) != len(urls) {
+		t.Fatalf("expected %d sources, found: %d", len(urls), len(srcs))
+	}
+	if len(srcs[1].URL) != 0 {
+		t.Error("failed source should be empty")
+	}
+	if srcs[0].URL != urls[0] {
+		t.Errorf("expected '%s', found: %s", urls[0], srcs[0].URL)
+	}
+	if ref := srcs[2].Ref; ref != "v1.0.1" {
+		t.Errorf("expected '%s', found: %s", "v1.0.1", ref)
+	}
+	if srcs[3].URL != urls[3] {
+		t.Errorf("expected '%s', found: %s", urls[3], srcs[3].URL)
+	}
+	if n := read[urls[3]]; n != int64(len("/three.tar.gz")) {
+		t.Errorf("expected %d bytes read, found: %d", len("/three.tar.gz"), n)
+	}
+}
+
+func TestUpdateSourcesContextCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	urls := []string{
+		"git|https://github.com/DataDrake/cuppa:v1.0",
+		"git|https://github.com/DataDrake/cuppa:v1.0.1",
+	}
+	_, err := UpdateSourcesContext(ctx, urls, 2, nil)
+	ses, ok := err.(SourceErrors)
+	if !ok {
+		t.Fatalf("expected SourceErrors, found: %v", err)
+	}
+	if len(ses) != 2 {
+		t.Fatalf("expected 2 errors, found: %d", len(ses))
+	}
+	for _, se := range ses {
+		if !errors.Is(se, context.Canceled) {
+			t.Errorf("expected context.Canceled, found: %s", se.Err)
 		}
 	}
 }
@@ -0,0 +1,217 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"errors"
+	"gopkg.in/yaml.v3"
+	"strings"
+)
+
+const (
+	// GitPrefix marks a source as a Git repository rather than an archive
+	GitPrefix = "git|"
+	// GitScheme is the Scheme of all Git sources
+	GitScheme = "git"
+)
+
+var (
+	// ErrUnsupportedSource indicates a URI which is not a Git, file, or HTTP(S) source
+	ErrUnsupportedSource = errors.New("unsupported source type")
+	// ErrNoGitRef indicates a Git URI which is missing the reference to check out
+	ErrNoGitRef = errors.New("no hash in git URI, should resemble 'git|http://path/to/repo:commit hash'")
+	// ErrInvalidSource indicates that a SourceURI is being read from invalid YAML
+	ErrInvalidSource = errors.New("Source must be a single URI mapped to a hash or Git reference")
+)
+
+// SourceURI is a single source for a package
+//
+// In YAML, a SourceURI is a single entry map of URI to hash or Git reference:
+//
+// - https://example.com/archive-1.0.tar.gz#renamed.tar.gz : <SHA256 hash>
+// - https://example.com/other-1.0.tar.gz : sha512:<SHA512 hash>
+// - git|https://example.com/repo.git : <Git reference>
+type SourceURI struct {
+	// Scheme is the kind of source, like "git", "https", or "file"
+	Scheme string
+	// URL is the location of the source, without the Git prefix or rename target
+	URL string
+	// Ref is the reference to check out for Git sources
+	Ref string
+	// Rename is the filename to save the source as, if different from the URL
+	Rename string
+	// Digest is the expected hash of the contents for non-Git sources
+	Digest Digest
+	// Comment is the line comment following this source in YAML
+	Comment string
+	// node is the original YAML, retained to keep comments and styling
+	node *yaml.Node
+}
+
+// ParseSourceURI reads a SourceURI from the URI format used when adding new sources
+//
+// Git sources must have a reference appended after a final colon, like "git|https://host:8080/repo.git:v1.0"
+func ParseSourceURI(URI string) (src SourceURI, err error) {
+	if strings.HasPrefix(URI, GitPrefix) {
+		src.Scheme = GitScheme
+		rest := strings.TrimPrefix(URI, GitPrefix)
+		// Skip past the authority so that a port is not mistaken for a reference
+		start := 0
+		if i := strings.Index(rest, "://"); i >= 0 {
+			start = i + 3
+			if j := strings.Index(rest[start:], "/"); j >= 0 {
+				start += j
+			} else {
+				start = len(rest)
+			}
+		}
+		sep := strings.LastIndex(rest[start:], ":")
+		if sep < 0 || start+sep == len(rest)-1 {
+			err = ErrNoGitRef
+			return
+		}
+		src.URL = rest[:start+sep]
+		src.Ref = rest[start+sep+1:]
+		return
+	}
+	switch {
+	case strings.HasPrefix(URI, "file://"):
+		src.Scheme = "file"
+	case strings.HasPrefix(URI, "http://"):
+		src.Scheme = "http"
+	case strings.HasPrefix(URI, "https://"):
+		src.Scheme = "https"
+	default:
+		err = ErrUnsupportedSource
+		return
+	}
+	src.URL, src.Rename = splitRename(URI)
+	return
+}
+
+// splitRename separates the rename target from the end of a URL
+func splitRename(URI string) (URL, rename string) {
+	URL = URI
+	if i := strings.LastIndex(URI, "#"); i >= 0 {
+		URL = URI[:i]
+		rename = URI[i+1:]
+	}
+	return
+}
+
+// IsGit checks if this is a Git source
+func (src SourceURI) IsGit() bool {
+	return src.Scheme == GitScheme
+}
+
+// Key gets the URI as it is written in YAML
+func (src SourceURI) Key() string {
+	if src.IsGit() {
+		return GitPrefix + src.URL
+	}
+	if len(src.Rename) > 0 {
+		return src.URL + "#" + src.Rename
+	}
+	return src.URL
+}
+
+// Value gets the hash or Git reference as it is written in YAML
+func (src SourceURI) Value() string {
+	if src.IsGit() {
+		return src.Ref
+	}
+	return src.Digest.String()
+}
+
+// String gets the URI in the same format accepted by ParseSourceURI
+func (src SourceURI) String() string {
+	if src.IsGit() {
+		return src.Key() + ":" + src.Ref
+	}
+	return src.Key()
+}
+
+// MarshalYAML writes a SourceURI as a single entry map, keeping any original comments
+func (src SourceURI) MarshalYAML() (out interface{}, err error) {
+	node := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			&yaml.Node{
+				Kind: yaml.ScalarNode,
+			},
+			&yaml.Node{
+				Kind: yaml.ScalarNode,
+			},
+		},
+	}
+	if src.node != nil {
+		orig := *src.node
+		key := *orig.Content[0]
+		value := *orig.Content[1]
+		orig.Content = []*yaml.Node{&key, &value}
+		node = &orig
+	}
+	node.Content[0].Value = src.Key()
+	node.Content[1].Value = src.Value()
+	node.Content[1].LineComment = src.Comment
+	out = node
+	return
+}
+
+// UnmarshalYAML reads a SourceURI from a single entry map
+func (src *SourceURI) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind != yaml.MappingNode || len(value.Content) != 2 {
+		return ErrInvalidSource
+	}
+	k, v := value.Content[0], value.Content[1]
+	if k.Kind != yaml.ScalarNode || len(k.Value) == 0 || v.Kind != yaml.ScalarNode {
+		return ErrInvalidSource
+	}
+	s := SourceURI{
+		Comment: v.LineComment,
+		node:    value,
+	}
+	if strings.HasPrefix(k.Value, GitPrefix) {
+		s.Scheme = GitScheme
+		s.URL = strings.TrimPrefix(k.Value, GitPrefix)
+		s.Ref = v.Value
+	} else {
+		if i := strings.Index(k.Value, "://"); i >= 0 {
+			s.Scheme = k.Value[:i]
+		}
+		s.URL, s.Rename = splitRename(k.Value)
+		s.Digest = ParseDigest(v.Value)
+	}
+	*src = s
+	return nil
+}
+
+// JSONSchema describes a SourceURI as a single entry map of URI to hash or Git reference
+func (src SourceURI) JSONSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":          "object",
+		"minProperties": 1,
+		"maxProperties": 1,
+		"propertyNames": map[string]interface{}{
+			"pattern": "^(git\\|.+|(https?|file)://.+)$",
+		},
+		"additionalProperties": map[string]interface{}{
+			"type":      "string",
+			"minLength": 1,
+		},
+	}
+}
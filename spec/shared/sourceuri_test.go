@@ -0,0 +1,129 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"gopkg.in/yaml.v3"
+	"strings"
+	"testing"
+)
+
+func TestParseSourceURIGitPort(t *testing.T) {
+	src, err := ParseSourceURI("git|https://git.example.com:8443/repo.git:release/1.0")
+	if err != nil {
+		t.Fatalf("expected no error, found: %s", err)
+	}
+	if src.Scheme != GitScheme {
+		t.Errorf("expected '%s', found: %s", GitScheme, src.Scheme)
+	}
+	if src.URL != "https://git.example.com:8443/repo.git" {
+		t.Errorf("expected '%s', found: %s", "https://git.example.com:8443/repo.git", src.URL)
+	}
+	if src.Ref != "release/1.0" {
+		t.Errorf("expected '%s', found: %s", "release/1.0", src.Ref)
+	}
+}
+
+func TestParseSourceURIGitNoRef(t *testing.T) {
+	for _, URI := range []string{
+		"git|https://git.example.com:8443/repo.git",
+		"git|https://git.example.com:8443",
+		"git|https://git.example.com/repo.git:",
+	} {
+		if _, err := ParseSourceURI(URI); err != ErrNoGitRef {
+			t.Errorf("expected ErrNoGitRef for '%s', found: %v", URI, err)
+		}
+	}
+}
+
+func TestParseSourceURIRename(t *testing.T) {
+	src, err := ParseSourceURI("https://example.com:8080/v1.0.tar.gz#cuppa-1.0.tar.gz")
+	if err != nil {
+		t.Fatalf("expected no error, found: %s", err)
+	}
+	if src.Scheme != "https" {
+		t.Errorf("expected '%s', found: %s", "https", src.Scheme)
+	}
+	if src.URL != "https://example.com:8080/v1.0.tar.gz" {
+		t.Errorf("expected '%s', found: %s", "https://example.com:8080/v1.0.tar.gz", src.URL)
+	}
+	if src.Rename != "cuppa-1.0.tar.gz" {
+		t.Errorf("expected '%s', found: %s", "cuppa-1.0.tar.gz", src.Rename)
+	}
+}
+
+func TestParseSourceURIInvalid(t *testing.T) {
+	if _, err := ParseSourceURI("bob"); err != ErrUnsupportedSource {
+		t.Fatalf("expected ErrUnsupportedSource, found: %v", err)
+	}
+}
+
+func TestSourceURIRoundTrip(t *testing.T) {
+	input := `source:
+    # head
+    - https://example.com/v1.0.tar.gz#cuppa-1.0.tar.gz : 97bb4ca8003fcf36075a968bd6bf80f864acac5d26284fb92e3fe6899ad92fd5 # line
+    - git|https://git.example.com:8443/repo.git : v1.0.1
+`
+	expected := `source:
+    # head
+    - https://example.com/v1.0.tar.gz#cuppa-1.0.tar.gz: 97bb4ca8003fcf36075a968bd6bf80f864acac5d26284fb92e3fe6899ad92fd5 # line
+    - git|https://git.example.com:8443/repo.git: v1.0.1
+`
+	var value struct {
+		Source []SourceURI `yaml:"source"`
+	}
+	if err := yaml.Unmarshal([]byte(input), &value); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if l := len(value.Source); l != 2 {
+		t.Fatalf("expected 2 sources, found: %d", l)
+	}
+	if src := value.Source[0]; src.Rename != "cuppa-1.0.tar.gz" || src.Comment != "# line" {
+		t.Errorf("expected rename and comment, found: %#v", src)
+	}
+	if src := value.Source[1]; src.URL != "https://git.example.com:8443/repo.git" || src.Ref != "v1.0.1" {
+		t.Errorf("expected Git URL and ref, found: %#v", src)
+	}
+	var out strings.Builder
+	enc := yaml.NewEncoder(&out)
+	if err := enc.Encode(value); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if result := out.String(); result != expected {
+		t.Fatalf("Expected %s, found: %s", expected, result)
+	}
+}
+
+func TestSourceURIMarshalNew(t *testing.T) {
+	expected := "- git|https://example.com/repo.git: 0123abcd # v1.0\n"
+	srcs := []SourceURI{
+		{
+			Scheme:  GitScheme,
+			URL:     "https://example.com/repo.git",
+			Ref:     "0123abcd",
+			Comment: "# v1.0",
+		},
+	}
+	var out strings.Builder
+	enc := yaml.NewEncoder(&out)
+	if err := enc.Encode(srcs); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if result := out.String(); result != expected {
+		t.Fatalf("Expected %s, found: %s", expected, result)
+	}
+}
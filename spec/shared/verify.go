@@ -0,0 +1,170 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Status is the outcome of verifying a single Source
+type Status int
+
+const (
+	// Verified means the Source matches what was recorded
+	Verified Status = iota
+	// Mismatch means the contents of the Source no longer match the recorded hash
+	Mismatch
+	// Unreachable means the contents of the Source could not be retrieved
+	Unreachable
+	// Duplicate means the Source is listed more than once
+	Duplicate
+	// MissingRef means the Git reference does not exist in the local clone
+	MissingRef
+	// Skipped means the Source could not be checked, like a Git Source without a local clone
+	Skipped
+	// Unsupported means the Source was recorded with a Digest Algorithm that is not supported
+	Unsupported
+	// BadSignature means the detached signature of the Source is missing or was not made by a key in the Keyring
+	BadSignature
+)
+
+var statusNames = map[Status]string{
+	Verified:     "verified",
+	Mismatch:     "mismatch",
+	Unreachable:  "unreachable",
+	Duplicate:    "duplicate",
+	MissingRef:   "missing ref",
+	Skipped:      "skipped",
+	Unsupported:  "unsupported",
+	BadSignature: "bad signature",
+}
+
+// String gets a human readable name for this Status
+func (s Status) String() string {
+	return statusNames[s]
+}
+
+// Verification is the result of checking a single Source
+type Verification struct {
+	URI      string
+	Expected string
+	Found    string
+	Status   Status
+	Err      error
+	src      SourceURI
+}
+
+// String summarizes this Verification in a single line
+func (v Verification) String() string {
+	switch v.Status {
+	case Mismatch:
+		return fmt.Sprintf("%s: %s, expected '%s', found '%s'", v.Status, v.URI, v.Expected, v.Found)
+	case Unreachable, MissingRef, Unsupported, BadSignature:
+		return fmt.Sprintf("%s: %s (%s)", v.Status, v.URI, v.Err)
+	default:
+		return fmt.Sprintf("%s: %s", v.Status, v.URI)
+	}
+}
+
+// Verifications are the results for every Source of a package
+type Verifications []Verification
+
+// OK checks that every Source was either Verified or Skipped
+func (vs Verifications) OK() bool {
+	for _, v := range vs {
+		if v.Status != Verified && v.Status != Skipped {
+			return false
+		}
+	}
+	return true
+}
+
+// VerifySources re-fetches every Source and checks it against its recorded hash
+//
+// The contents of HTTP(S) sources are always downloaded again, bypassing any Cache, and are
+// hashed with the same Algorithm as their recorded Digest. If the Fetcher has a Keyring, their
+// detached signatures are checked again too. Git
+// references are checked against the local clone found at "clone", or Skipped if empty.
+func (f *Fetcher) VerifySources(ctx context.Context, srcs []SourceURI, clone string, workers int) (vs Verifications) {
+	fresh := *f
+	fresh.Cache = nil
+	seen := make(map[string]bool)
+	var todo []int
+	for _, src := range srcs {
+		v := Verification{
+			URI:      src.Key(),
+			Expected: src.Value(),
+			src:      src,
+		}
+		if seen[v.URI] {
+			v.Status = Duplicate
+		} else {
+			todo = append(todo, len(vs))
+		}
+		seen[v.URI] = true
+		vs = append(vs, v)
+	}
+	parallel(len(todo), workers, func(i int) {
+		v := &vs[todo[i]]
+		if v.src.IsGit() {
+			v.verifyRef(ctx, clone)
+			return
+		}
+		// Hash with the same Algorithm as the recorded Digest
+		if _, err := v.src.Digest.Algorithm.New(); err != nil {
+			v.Status = Unsupported
+			v.Err = err
+			return
+		}
+		g := fresh
+		g.Algorithm = v.src.Digest.Algorithm
+		src, err := g.NewSource(ctx, v.src.String(), nil)
+		if err != nil {
+			v.Status = Unreachable
+			if errors.Is(err, ErrBadSignature) || errors.Is(err, ErrNoSignature) {
+				v.Status = BadSignature
+			}
+			v.Err = err
+			return
+		}
+		if v.Found = src.Value(); v.Found != v.Expected {
+			v.Status = Mismatch
+		}
+	})
+	return
+}
+
+// verifyRef checks that the expected Git reference exists in a local clone
+func (v *Verification) verifyRef(ctx context.Context, clone string) {
+	if len(clone) == 0 {
+		v.Status = Skipped
+		return
+	}
+	for _, ref := range []string{v.Expected, "origin/" + v.Expected} {
+		cmd := exec.CommandContext(ctx, "git", "-C", clone, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+		if out, err := cmd.Output(); err == nil {
+			v.Found = strings.TrimSpace(string(out))
+			return
+		}
+	}
+	v.Status = MissingRef
+	v.Err = fmt.Errorf("no commit for '%s' in '%s'", v.Expected, clone)
+}
@@ -0,0 +1,134 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package shared
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// newGitRepo creates a repository with a single tagged commit
+func newGitRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	cmds := [][]string{
+		{"init", "-q"},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+		{"tag", "v1.0.1"},
+	}
+	for _, args := range cmds {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Expected no error, found: %s\n%s", err, out)
+		}
+	}
+	return dir
+}
+
+func TestVerifySources(t *testing.T) {
+	srv := httptest.NewServer(http.FileServer(http.Dir("TESTING")))
+	defer srv.Close()
+	clone := newGitRepo(t)
+	sum := "d17245c4f327262bb7c4d7571a95d71d452bb6073331d7866b289154be6396ba"
+	var srcs []SourceURI
+	for _, entry := range [][2]string{
+		{srv.URL + "/file.md", sum},
+		{srv.URL + "/file.md", sum},
+		{srv.URL + "/file.md?rerolled", "0000"},
+		{srv.URL + "/missing.tar.gz", sum},
+		{"git|https://github.com/DataDrake/cuppa:v1.0.1", ""},
+		{"git|https://github.com/DataDrake/cuppa.git:v9.9.9", ""},
+		{srv.URL + "/file.md?md5", "md5:" + sum},
+	} {
+		src, err := ParseSourceURI(entry[0])
+		if err != nil {
+			t.Fatalf("Expected no error, found: %s", err)
+		}
+		src.Digest = ParseDigest(entry[1])
+		srcs = append(srcs, src)
+	}
+	expected := []Status{Verified, Duplicate, Mismatch, Unreachable, Verified, MissingRef, Unsupported}
+	f := &Fetcher{
+		Cache: NewCache(t.TempDir()),
+	}
+	vs := f.VerifySources(context.Background(), srcs, clone, 2)
+	if len(vs) != len(expected) {
+		t.Fatalf("expected %d results, found: %d", len(expected), len(vs))
+	}
+	for i, v := range vs {
+		if v.Status != expected[i] {
+			t.Errorf("expected '%s' for '%s', found: %s", expected[i], v.URI, v)
+		}
+	}
+	if vs[2].Found != sum {
+		t.Errorf("expected '%s', found: %s", sum, vs[2].Found)
+	}
+	if vs.OK() {
+		t.Error("expected verification to fail")
+	}
+}
+
+func TestVerifySourcesSkipGit(t *testing.T) {
+	src, err := ParseSourceURI("git|https://github.com/DataDrake/cuppa:v1.0.1")
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	srcs := []SourceURI{src}
+	vs := DefaultFetcher.VerifySources(context.Background(), srcs, "", 1)
+	if len(vs) != 1 {
+		t.Fatalf("expected 1 result, found: %d", len(vs))
+	}
+	if vs[0].Status != Skipped {
+		t.Errorf("expected '%s', found: %s", Skipped, vs[0].Status)
+	}
+	if !vs.OK() {
+		t.Error("expected verification to pass")
+	}
+}
+
+func TestVerifySourcesSignature(t *testing.T) {
+	dir, keyring := newSignedSource(t)
+	if err := ioutil.WriteFile(filepath.Join(dir, "unsigned.md"), []byte(cacheContent), 0644); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	var srcs []SourceURI
+	for _, name := range []string{"file.md", "unsigned.md"} {
+		src, err := ParseSourceURI("file://" + filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Expected no error, found: %s", err)
+		}
+		src.Digest = ParseDigest(cacheSum)
+		srcs = append(srcs, src)
+	}
+	f := &Fetcher{
+		Keyring: keyring,
+	}
+	vs := f.VerifySources(context.Background(), srcs, "", 1)
+	expected := []Status{Verified, BadSignature}
+	for i, v := range vs {
+		if v.Status != expected[i] {
+			t.Errorf("expected '%s' for '%s', found: %s", expected[i], v.URI, v)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package version
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidVersion indicates a version string which is empty or contains unexpected characters
+var ErrInvalidVersion = errors.New("version must only contain letters, digits and '.', '_', '-', '+' or '~'")
+
+// keywords are the release markers understood by eopkg, in order
+//
+// Everything before "rc" is a pre-release, ordered before the plain version, while "p"
+// marks a patch level ordered after it.
+var keywords = map[string]int{
+	"alpha": -4,
+	"beta":  -3,
+	"pre":   -2,
+	"rc":    -1,
+	"p":     1,
+}
+
+// token is a single run of digits or letters in a Version
+type token struct {
+	// number is a run of digits without leading zeros, so that it can be as long as needed
+	number string
+	word   string
+}
+
+// isNumber checks if this token is a run of digits
+func (t token) isNumber() bool {
+	return len(t.word) == 0
+}
+
+// rank orders a word token relative to the end of a version
+//
+// Pre-release keywords are negative, other words are positive.
+func (t token) rank() int {
+	if r, ok := keywords[t.word]; ok {
+		return r
+	}
+	return 1
+}
+
+// compare orders two tokens
+//
+// Numbers are compared numerically and always come after words, so that "1.0a" is
+// older than "1.0.1". Pre-release keywords come before any other word.
+func (t token) compare(o token) int {
+	switch {
+	case t.isNumber() && o.isNumber():
+		return compareNumbers(t.number, o.number)
+	case t.isNumber():
+		return 1
+	case o.isNumber():
+		return -1
+	}
+	if r := compareInts(t.rank(), o.rank()); r != 0 {
+		return r
+	}
+	return strings.Compare(t.word, o.word)
+}
+
+// compareNumbers orders two runs of digits without leading zeros, where a longer run is always larger
+func compareNumbers(a, b string) int {
+	if r := compareInts(len(a), len(b)); r != 0 {
+		return r
+	}
+	return strings.Compare(a, b)
+}
+
+// compareInts orders two integers
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// Version is a package version which can be ordered following the rules of eopkg
+//
+// Versions are split into runs of digits and letters, like "1.0.0a" into 1, 0, 0, a.
+// Separators are only used for splitting, so "1.0_rc1", "1.0-rc1" and "1.0rc1" are equal.
+// The suffixes "alpha", "beta", "pre" and "rc" make an earlier version than the plain
+// one, while any other letters, like "a" or "p1", make a later one:
+//
+//	1.0alpha < 1.0beta2 < 1.0pre < 1.0rc1 < 1.0 < 1.0a < 1.0p1 < 1.0.1
+type Version struct {
+	raw    string
+	tokens []token
+}
+
+// Parse reads a Version from a string
+func Parse(s string) (v Version, err error) {
+	v.raw = s
+	s = strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(s, "v"), "V"))
+	if len(s) == 0 {
+		err = ErrInvalidVersion
+		return
+	}
+	for len(s) > 0 {
+		c := s[0]
+		switch {
+		case c == '.' || c == '_' || c == '-' || c == '+' || c == '~':
+			s = s[1:]
+		case c >= '0' && c <= '9':
+			end := 1
+			for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+				end++
+			}
+			v.tokens = append(v.tokens, token{number: strings.TrimLeft(s[:end], "0")})
+			s = s[end:]
+		case c >= 'a' && c <= 'z':
+			end := 1
+			for end < len(s) && s[end] >= 'a' && s[end] <= 'z' {
+				end++
+			}
+			v.tokens = append(v.tokens, token{word: s[:end]})
+			s = s[end:]
+		default:
+			err = ErrInvalidVersion
+			return
+		}
+	}
+	if len(v.tokens) == 0 {
+		err = ErrInvalidVersion
+	}
+	return
+}
+
+// String gets the Version as it was originally written
+func (v Version) String() string {
+	return v.raw
+}
+
+// Compare orders two Versions, returning -1, 0 or 1 when "v" is older, equal or newer than "o"
+func (v Version) Compare(o Version) int {
+	for i := 0; i < len(v.tokens) && i < len(o.tokens); i++ {
+		if r := v.tokens[i].compare(o.tokens[i]); r != 0 {
+			return r
+		}
+	}
+	switch {
+	case len(v.tokens) > len(o.tokens):
+		return v.tail(len(o.tokens))
+	case len(v.tokens) < len(o.tokens):
+		return -o.tail(len(v.tokens))
+	}
+	return 0
+}
+
+// tail orders the extra tokens of a longer Version against the end of a shorter one
+//
+// "1.0rc1" is older than "1.0", but "1.0.1" and "1.0a" are newer.
+func (v Version) tail(i int) int {
+	if t := v.tokens[i]; !t.isNumber() && t.rank() < 0 {
+		return -1
+	}
+	return 1
+}
+
+// Less checks if "v" is older than "o"
+func (v Version) Less(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// Compare orders two version strings, returning -1, 0 or 1 when "a" is older, equal or newer than "b"
+//
+// Invalid versions are always older than valid ones, and are compared as plain strings to each other.
+func Compare(a, b string) int {
+	va, aerr := Parse(a)
+	vb, berr := Parse(b)
+	switch {
+	case aerr != nil && berr != nil:
+		return strings.Compare(a, b)
+	case aerr != nil:
+		return -1
+	case berr != nil:
+		return 1
+	}
+	return va.Compare(vb)
+}
+
+// Sort orders a list of version strings from oldest to newest
+func Sort(versions []string) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return Compare(versions[i], versions[j]) < 0
+	})
+}
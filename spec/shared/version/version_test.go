@@ -0,0 +1,83 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package version
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.0", "1.0", 0},
+		{"1.0.0a", "1.0.0a", 0},
+		{"1.0", "1.1", -1},
+		{"1.10", "1.9", 1},
+		{"1.0", "1.0.1", -1},
+		{"1.0.0", "1.0.0a", -1},
+		{"1.0.0a", "1.0.0b", -1},
+		{"1.0.0a", "1.0.1", -1},
+		{"1.0alpha", "1.0beta", -1},
+		{"1.0beta2", "1.0pre", -1},
+		{"1.0pre", "1.0rc1", -1},
+		{"1.0rc1", "1.0rc2", -1},
+		{"1.0rc1", "1.0", -1},
+		{"1.0_rc1", "1.0rc1", 0},
+		{"1.0-beta1", "1.0_beta1", 0},
+		{"1.0", "1.0_p1", -1},
+		{"1.0p1", "1.0.1", -1},
+		{"v2.3", "2.3", 0},
+		{"2021.03.14", "2020.12.31", 1},
+		{"3.38.1", "40.0", -1},
+		{"1.01", "1.1", 0},
+		{"1.0.0", "1.00.000", 0},
+		{"0.0.20210322153248", "0.0.20210321000000", 1},
+		{"1.99999999999999999999", "1.100000000000000000000", -1},
+		{"1.123456789012345678901234567890", "1.2", 1},
+	}
+	for _, c := range cases {
+		if result := Compare(c.a, c.b); result != c.expected {
+			t.Errorf("expected %d comparing '%s' to '%s', found: %d", c.expected, c.a, c.b, result)
+		}
+		if result := Compare(c.b, c.a); result != -c.expected {
+			t.Errorf("expected %d comparing '%s' to '%s', found: %d", -c.expected, c.b, c.a, result)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, s := range []string{"", "v", "1.0 beta", "1.0/2", "..."} {
+		if _, err := Parse(s); err != ErrInvalidVersion {
+			t.Errorf("expected ErrInvalidVersion for '%s', found: %v", s, err)
+		}
+	}
+	if Compare("1.0 beta", "0.1") != -1 {
+		t.Error("expected invalid versions to be older than valid ones")
+	}
+}
+
+func TestSort(t *testing.T) {
+	versions := []string{"1.0.1", "1.0", "1.0rc1", "1.0a", "0.9", "1.0beta", "1.0p1"}
+	expected := []string{"0.9", "1.0beta", "1.0rc1", "1.0", "1.0a", "1.0p1", "1.0.1"}
+	Sort(versions)
+	if !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("expected %v, found: %v", expected, versions)
+	}
+}
@@ -17,18 +17,28 @@
 package spec
 
 import (
+	"bytes"
+	"context"
 	"dev.getsol.us/source/libypkg.git/spec/internal"
+	"dev.getsol.us/source/libypkg.git/spec/shared"
 	"dev.getsol.us/source/libypkg.git/spec/v2"
 	"dev.getsol.us/source/libypkg.git/spec/v3"
 	"errors"
+	"fmt"
 	"gopkg.in/yaml.v3"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 )
 
 var (
 	// ErrInvalidVersion is returned when the requested YPKG format is unsupported
 	ErrInvalidVersion = errors.New("invalid ypkg version specified")
+	// ErrPlaceholders is returned when a guided Init is stopped before every placeholder was replaced
+	ErrPlaceholders = errors.New("package.yml still contains placeholders from the template")
+	// ErrNoProgress is returned when a Guide returns without changing the package.yml
+	ErrNoProgress = errors.New("package.yml was not changed")
 )
 
 // Package is a common interface to all version of the Package YML specification
@@ -68,7 +78,11 @@ func DetectFormat(path string) (ypkg int, err error) {
 		return
 	}
 	defer f.Close()
-	dec := yaml.NewDecoder(f)
+	return detectFormat(yaml.NewDecoder(f))
+}
+
+// detectFormat decodes just the version of the format, which defaults to 2
+func detectFormat(dec *yaml.Decoder) (ypkg int, err error) {
 	var version struct {
 		YPKG string `yaml:"YPKG"`
 	}
@@ -83,6 +97,19 @@ func DetectFormat(path string) (ypkg int, err error) {
 	return
 }
 
+// Parse reads any supported package.yml from its contents, without an underlying file
+func Parse(data []byte) (pkg Package, err error) {
+	ypkg, err := detectFormat(yaml.NewDecoder(bytes.NewReader(data)))
+	if err != nil {
+		return
+	}
+	if pkg, err = NewPackage(ypkg, nil); err != nil {
+		return
+	}
+	err = yaml.Unmarshal(data, pkg)
+	return
+}
+
 // NewPackage creates and empty package of the specified version, if supported
 func NewPackage(ypkg int, f *os.File) (pkg Package, err error) {
 	switch ypkg {
@@ -97,8 +124,10 @@ func NewPackage(ypkg int, f *os.File) (pkg Package, err error) {
 }
 
 // Auto generates a new package my inspecting the contents of a list of sources
-func Auto(sources []string) (pkg Package, err error) {
-	def, err := internal.Auto(sources)
+//
+// The sources are fetched with "f", or the shared.DefaultFetcher if nil.
+func Auto(sources []string, f *shared.Fetcher) (pkg Package, err error) {
+	def, err := internal.Auto(sources, f)
 	if err != nil {
 		return
 	}
@@ -154,6 +183,74 @@ func Init(path string) (pkg Package, err error) {
 	return
 }
 
+// Guide is called by InitGuided with every placeholder left in a new package.yml, so that they can be replaced
+//
+// The file at path is read again after the Guide returns. Returning an error stops InitGuided, and so does
+// returning without changing the file, since the same placeholders would only be found again.
+type Guide func(path string, remaining Problems) error
+
+// GuideError is returned when InitGuided stops before every placeholder is replaced
+//
+// It matches ErrPlaceholders with errors.Is, and unwraps to the reason the Guide stopped.
+type GuideError struct {
+	Err error
+}
+
+// Error gets the error message, followed by the reason the Guide stopped
+func (ge *GuideError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrPlaceholders, ge.Err)
+}
+
+// Unwrap gets the reason the Guide stopped
+func (ge *GuideError) Unwrap() error {
+	return ge.Err
+}
+
+// Is matches ErrPlaceholders
+func (ge *GuideError) Is(target error) bool {
+	return target == ErrPlaceholders
+}
+
+// InitGuided creates a new package.yml like Init, but does not finish until every placeholder is replaced
+func InitGuided(path string, guide Guide) (pkg Package, err error) {
+	if pkg, err = Init(path); err != nil {
+		return
+	}
+	if err = pkg.Save(); err != nil {
+		return
+	}
+	pkg.Close()
+	for {
+		if pkg, err = Load(path); err != nil {
+			return
+		}
+		var i *internal.PackageYML
+		if i, err = pkg.Convert(); err != nil {
+			return
+		}
+		remaining := i.Placeholders()
+		if len(remaining) == 0 {
+			return
+		}
+		pkg.Close()
+		var before, after []byte
+		if before, err = ioutil.ReadFile(path); err != nil {
+			return
+		}
+		if err = guide(path, remaining); err != nil {
+			err = &GuideError{Err: err}
+			return
+		}
+		if after, err = ioutil.ReadFile(path); err != nil {
+			return
+		}
+		if bytes.Equal(before, after) {
+			err = &GuideError{Err: ErrNoProgress}
+			return
+		}
+	}
+}
+
 // Lint checks for errors and common mistakes in package.yml
 func Lint(path string) (pkg Package, err error) {
 	if pkg, err = Load(path); err != nil {
@@ -168,7 +265,12 @@ func Lint(path string) (pkg Package, err error) {
 }
 
 // Update modifies the sources in an existing package.yml and overwrites the existing file
-func Update(path, version string, sources []string) (pkg Package, err error) {
+//
+// The sources are fetched with "f", or the shared.DefaultFetcher if nil, so that caching, Offline
+// mode and the other options of a Fetcher, like pinning Git references to commits with ResolveRefs
+// and GitMirrors, can be chosen for each call. Signatures are checked
+// against the Keyring next to the package.yml, unless the Fetcher already has one.
+func Update(path, version string, sources []string, f *shared.Fetcher) (pkg Package, err error) {
 	original, err := Load(path)
 	if err != nil {
 		return
@@ -179,13 +281,108 @@ func Update(path, version string, sources []string) (pkg Package, err error) {
 	}
 	original.Close()
 	i.Bump()
-	if err = i.Update(version, sources); err != nil {
+	if err = i.Update(version, sources, fetcher(f, path)); err != nil {
 		return
 	}
-	pkg = &v2.PackageYML{}
+	pkg = v2.NewPackage(nil)
 	if err = pkg.Load(path, os.O_CREATE); err != nil {
 		return
 	}
 	err = pkg.Modify(*i)
 	return
 }
+
+// Verify downloads the sources of a package.yml again and checks them against their recorded hashes
+//
+// Git sources are checked against a local clone of the repository, or skipped if "clone" is empty.
+// The sources are fetched with "f", or the shared.DefaultFetcher if nil, and their signatures are
+// checked against the Keyring next to the package.yml, unless the Fetcher already has one.
+func Verify(path, clone string, f *shared.Fetcher) (vs shared.Verifications, err error) {
+	h, err := LoadHeader(path)
+	if err != nil {
+		return
+	}
+	vs = fetcher(f, path).VerifySources(context.Background(), h.Source, clone, 4)
+	return
+}
+
+// Keyring gets the location of the trusted upstream keys kept next to a package.yml, or "" if there are none
+func Keyring(path string) string {
+	keyring := filepath.Join(filepath.Dir(path), shared.KeyringName)
+	if _, err := os.Stat(keyring); err != nil {
+		return ""
+	}
+	return keyring
+}
+
+// fetcher gets a copy of "f", or the shared.DefaultFetcher if nil, using the Keyring of a package.yml if it has none
+func fetcher(f *shared.Fetcher, path string) *shared.Fetcher {
+	if f == nil {
+		f = shared.DefaultFetcher
+	}
+	g := *f
+	if len(g.Keyring) == 0 {
+		g.Keyring = Keyring(path)
+	}
+	return &g
+}
+
+// loadInternal reads any supported package.yml as an internal.PackageYML, without keeping the file open
+func loadInternal(path string) (i *internal.PackageYML, err error) {
+	pkg, err := Load(path)
+	if err != nil {
+		return
+	}
+	defer pkg.Close()
+	return pkg.Convert()
+}
+
+// Diff finds the semantic differences between two package.yml files of any supported version
+func Diff(old, new string) (changes internal.Changes, err error) {
+	a, err := loadInternal(old)
+	if err != nil {
+		return
+	}
+	b, err := loadInternal(new)
+	if err != nil {
+		return
+	}
+	changes = internal.Diff(a, b)
+	return
+}
+
+// MergeFiles combines the changes made to a common "base" package.yml by "ours" and "theirs", writing the result to "ours"
+//
+// This works as a git merge driver for "%O %A %B". The result keeps the version of the
+// format used by "ours". Any fields which could not be merged are returned as
+// internal.Conflicts, after the result has been written.
+func MergeFiles(base, ours, theirs string) (err error) {
+	b, err := loadInternal(base)
+	if err != nil {
+		return
+	}
+	t, err := loadInternal(theirs)
+	if err != nil {
+		return
+	}
+	pkg, err := Load(ours)
+	if err != nil {
+		return
+	}
+	defer pkg.Close()
+	o, err := pkg.Convert()
+	if err != nil {
+		return
+	}
+	merged, conflicts := internal.Merge(b, o, t)
+	if err = pkg.Modify(*merged); err != nil {
+		return
+	}
+	if err = pkg.Save(); err != nil {
+		return
+	}
+	if len(conflicts) > 0 {
+		err = conflicts
+	}
+	return
+}
@@ -0,0 +1,81 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/internal"
+)
+
+// The results of linting, diffing and merging are defined by the internal model, but
+// aliased here so that they can be used outside of libypkg.
+type (
+	// Severity is how serious a Problem is
+	Severity = internal.Severity
+	// Problem is a single issue found by Lint
+	Problem = internal.Problem
+	// Problems are all of the issues found by Lint
+	Problems = internal.Problems
+	// Change is a single semantic difference found by Diff
+	Change = internal.Change
+	// Changes are all of the semantic differences found by Diff
+	Changes = internal.Changes
+	// Conflict is a field which MergeFiles could not merge automatically
+	Conflict = internal.Conflict
+	// Conflicts are every field which MergeFiles could not merge automatically
+	Conflicts = internal.Conflicts
+	// Arch is the kind of binaries built by a single pass of the build
+	Arch = internal.Arch
+	// Toolchain is the compiler and flags used for a single pass of the build
+	Toolchain = internal.Toolchain
+	// Pass is a single run through the build stages of a package
+	Pass = internal.Pass
+	// PGO is the part a Pass plays in a profile guided build
+	PGO = internal.PGO
+	// Environment is the parsed "environment" of a package
+	Environment = internal.Environment
+	// Variable is a single assignment in an Environment
+	Variable = internal.Variable
+	// Override is a Variable which replaces a value decided by the BuildFlags
+	Override = internal.Override
+)
+
+const (
+	// SeverityError is a Problem which will stop the package from building or being accepted
+	SeverityError = internal.Error
+	// SeverityWarning is a questionable choice which should be reviewed
+	SeverityWarning = internal.Warning
+	// Native builds 64-bit binaries for any x86_64 processor
+	Native = internal.Native
+	// Emul32 builds 32-bit libraries
+	Emul32 = internal.Emul32
+	// AVX2 builds 64-bit libraries for processors with AVX2
+	AVX2 = internal.AVX2
+	// NoPGO is a Pass which does not use a profile
+	NoPGO = internal.NoPGO
+	// Generate is a Pass which creates a profile
+	Generate = internal.Generate
+	// Use is a Pass which builds with a profile
+	Use = internal.Use
+)
+
+// Optimizations are the values understood by ypkg for the "optimize" flag
+var Optimizations = internal.Optimizations
+
+// ParseEnvironment reads the variables set by the "environment" of a package
+func ParseEnvironment(script string) *Environment {
+	return internal.ParseEnvironment(script)
+}
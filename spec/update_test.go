@@ -0,0 +1,83 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestUpdateFetcher(t *testing.T) {
+	dir := t.TempDir()
+	path := writeHeaderTest(t, dir, "package.yml", headerV2)
+	f := &shared.Fetcher{
+		Cache: shared.NewCache(t.TempDir()),
+	}
+	f.Cache.Offline = true
+	if _, err := Update(path, "1.16.3", nil, f); err == nil {
+		t.Fatal("expected a cache miss")
+	}
+	hash, err := f.Cache.Store("https://dl.google.com/go/go1.16.3.src.tar.gz", strings.NewReader("go1.16.3"))
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	pkg, err := Update(path, "1.16.3", nil, f)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	defer pkg.Close()
+	i, err := pkg.Convert()
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if len(i.Source) != 1 || i.Source[0].Digest.Value != hash {
+		t.Errorf("expected '%s', found: %v", hash, i.Source)
+	}
+}
+
+func TestUpdateResolveRefs(t *testing.T) {
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	for _, tag := range []string{"v1.0.1", "v1.0.2"} {
+		runGit(t, repo, "commit", "-q", "--allow-empty", "-m", tag)
+		runGit(t, repo, "tag", tag)
+	}
+	out, err := exec.Command("git", "-C", repo, "rev-parse", "HEAD").Output()
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	commit := strings.TrimSpace(string(out))
+	content := "name: foo\nversion: 1.0.1\nrelease: 1\nsource:\n    - git|file://" + repo + " : v1.0.1\n"
+	path := writeHeaderTest(t, t.TempDir(), "package.yml", content)
+	f := &shared.Fetcher{
+		ResolveRefs: true,
+	}
+	pkg, err := Update(path, "1.0.2", nil, f)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	defer pkg.Close()
+	i, err := pkg.Convert()
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if len(i.Source) != 1 || i.Source[0].Ref != commit || i.Source[0].Comment != "# v1.0.2" {
+		t.Errorf("expected '%s # v1.0.2', found: %v", commit, i.Source)
+	}
+}
@@ -27,21 +27,21 @@ import (
 
 // PackageYML is the v3 representation of the Package YML specification
 type PackageYML struct {
-	Name         string          `yaml:"name"`
-	Version      string          `yaml:"version"`
-	Release      uint            `yaml:"release"`
-	Source       []shared.Source `yaml:"source"`
-	Homepage     string          `yaml:"homepage,omitempty"`
-	License      shared.Licenses `yaml:"license"`
-	Component    array.Map       `yaml:"component"`
-	Summary      array.Map       `yaml:"summary"`
-	Description  array.Map       `yaml:"description"`
-	Dependencies PackageDeps     `yaml:"dependencies,inline,omitempty"`
-	Flags        BuildFlags      `yaml:",omitempty,inline"`
-	Environment  string          `yaml:"environment,omitempty"`
-	Stages       BuildStages     `yaml:",inline"`
-	Permanent    array.ListMap   `yaml:"permanent,omitempty"`
-	Patterns     array.ListMap   `yaml:"patterns,omitempty"`
+	Name         string             `yaml:"name"`
+	Version      string             `yaml:"version"`
+	Release      uint               `yaml:"release"`
+	Source       []shared.SourceURI `yaml:"source"`
+	Homepage     string             `yaml:"homepage,omitempty"`
+	License      shared.Licenses    `yaml:"license"`
+	Component    array.Map          `yaml:"component"`
+	Summary      array.Map          `yaml:"summary"`
+	Description  array.Map          `yaml:"description"`
+	Dependencies PackageDeps        `yaml:"dependencies,inline,omitempty"`
+	Flags        BuildFlags         `yaml:",omitempty,inline"`
+	Environment  string             `yaml:"environment,omitempty"`
+	Stages       BuildStages        `yaml:",inline"`
+	Permanent    array.ListMap      `yaml:"permanent,omitempty"`
+	Patterns     array.ListMap      `yaml:"patterns,omitempty"`
 	f            *os.File
 }
 
@@ -26,25 +26,25 @@ import (
 
 // PackageYML is the v3 representation of the Package YML specification
 type PackageYML struct {
-	YPKG         int             `yaml:"YPKG"`
-	Name         string          `yaml:"name"`
-	Version      string          `yaml:"version"`
-	Release      uint            `yaml:"release"`
-	Source       []shared.Source `yaml:"source"`
-	Homepage     string          `yaml:"homepage,omitempty"`
-	License      shared.Licenses `yaml:"license"`
-	Component    string          `yaml:"component,omitempty"`
-	Components   array.Map       `yaml:"components,omitempty"`
-	Summary      string          `yaml:"summary,omitempty"`
-	Summaries    array.Map       `yaml:"summaries,omitempty"`
-	Description  string          `yaml:"description,omitempty"`
-	Descriptions array.Map       `yaml:"descriptions,omitempty"`
-	Dependencies PackageDeps     `yaml:"deps,omitempty"`
-	Flags        BuildFlags      `yaml:"flags,omitempty"`
-	Environment  string          `yaml:"environment,omitempty"`
-	Stages       BuildStages     `yaml:",inline"`
-	Permanent    array.ListMap   `yaml:"permanent,omitempty"`
-	Patterns     array.ListMap   `yaml:"patterns,omitempty"`
+	YPKG         int                `yaml:"YPKG"`
+	Name         string             `yaml:"name"`
+	Version      string             `yaml:"version"`
+	Release      uint               `yaml:"release"`
+	Source       []shared.SourceURI `yaml:"source"`
+	Homepage     string             `yaml:"homepage,omitempty"`
+	License      shared.Licenses    `yaml:"license"`
+	Component    string             `yaml:"component,omitempty"`
+	Components   array.Map          `yaml:"components,omitempty"`
+	Summary      string             `yaml:"summary,omitempty"`
+	Summaries    array.Map          `yaml:"summaries,omitempty"`
+	Description  string             `yaml:"description,omitempty"`
+	Descriptions array.Map          `yaml:"descriptions,omitempty"`
+	Dependencies PackageDeps        `yaml:"deps,omitempty"`
+	Flags        BuildFlags         `yaml:"flags,omitempty"`
+	Environment  string             `yaml:"environment,omitempty"`
+	Stages       BuildStages        `yaml:",inline"`
+	Permanent    array.ListMap      `yaml:"permanent,omitempty"`
+	Patterns     array.ListMap      `yaml:"patterns,omitempty"`
 	f            *os.File
 }
 
@@ -0,0 +1,56 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package spec
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+// unsignedPackage writes a package.yml with an unsigned local source, next to a keyring
+func unsignedPackage(t *testing.T) string {
+	dir := t.TempDir()
+	for _, name := range []string{"foo-1.0.tar.gz", "foo-1.1.tar.gz", shared.KeyringName} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatalf("Expected no error, found: %s", err)
+		}
+	}
+	src := "file://" + filepath.Join(dir, "foo-1.0.tar.gz")
+	content := "name: foo\nversion: '1.0'\nrelease: 1\nsource:\n    - " + src + " : 1e0a1bbeda7bd9d2ac2d6e4e1f34d4c3a4a3bb3c2e0ed4e0f5c6a2b8a63bd58c\n"
+	return writeHeaderTest(t, dir, "package.yml", content)
+}
+
+func TestVerifyKeyring(t *testing.T) {
+	vs, err := Verify(unsignedPackage(t), "", nil)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if len(vs) != 1 || vs[0].Status != shared.BadSignature {
+		t.Errorf("expected '%s', found: %v", shared.BadSignature, vs)
+	}
+}
+
+func TestUpdateKeyring(t *testing.T) {
+	_, err := Update(unsignedPackage(t), "1.1", nil, nil)
+	ses, ok := err.(shared.SourceErrors)
+	if !ok || len(ses) != 1 || !errors.Is(ses[0], shared.ErrNoSignature) {
+		t.Errorf("expected '%s', found: %v", shared.ErrNoSignature, err)
+	}
+}
@@ -0,0 +1,83 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package upstream
+
+import (
+	"context"
+	"regexp"
+	"strings"
+)
+
+// distPattern matches the name of a CPAN distribution archive, like "Foo-Bar-1.23.tar.gz"
+var distPattern = regexp.MustCompile(`^(.+?)-v?[0-9][^-]*\.(tar\.gz|tar\.bz2|tgz|zip)$`)
+
+// CPAN finds the releases of Perl distributions on CPAN
+type CPAN struct {
+	// BaseURL is the location of the MetaCPAN API, "https://fastapi.metacpan.org" if empty
+	BaseURL string
+}
+
+// Name identifies this Provider in reports
+func (p *CPAN) Name() string {
+	return "cpan"
+}
+
+// Match gets the name of a distribution from an archive or release page
+func (p *CPAN) Match(URL string) (id string, ok bool) {
+	host, parts := splitURL(URL)
+	switch host {
+	case "cpan.metacpan.org", "www.cpan.org", "cpan.org", "search.cpan.org", "backpan.perl.org":
+	case "metacpan.org":
+		if len(parts) >= 2 && (parts[0] == "release" || parts[0] == "dist") {
+			id = parts[1]
+			ok = true
+		}
+		return
+	default:
+		return
+	}
+	if len(parts) == 0 {
+		return
+	}
+	if match := distPattern.FindStringSubmatch(parts[len(parts)-1]); match != nil {
+		id = match[1]
+		ok = true
+	}
+	return
+}
+
+// Latest finds the newest release of a distribution
+func (p *CPAN) Latest(ctx context.Context, c *Client, id string) (r Release, err error) {
+	base := p.BaseURL
+	if len(base) == 0 {
+		base = "https://fastapi.metacpan.org"
+	}
+	var release struct {
+		Version     string `json:"version"`
+		DownloadURL string `json:"download_url"`
+	}
+	if err = c.getJSON(ctx, base+"/v1/release/"+id, nil, &release); err != nil {
+		return
+	}
+	if len(release.Version) == 0 {
+		err = ErrNoRelease
+		return
+	}
+	r.Version = strings.TrimPrefix(release.Version, "v")
+	r.URL = release.DownloadURL
+	return
+}
@@ -0,0 +1,74 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package upstream
+
+import (
+	"context"
+)
+
+// Crates finds the releases of Rust crates on crates.io
+type Crates struct {
+	// BaseURL is the location of the API, "https://crates.io" if empty
+	BaseURL string
+}
+
+// Name identifies this Provider in reports
+func (cr *Crates) Name() string {
+	return "crates.io"
+}
+
+// Match gets the name of a crate from a download or crate page
+func (cr *Crates) Match(URL string) (id string, ok bool) {
+	host, parts := splitURL(URL)
+	switch {
+	case host == "crates.io" && len(parts) >= 4 && parts[0] == "api" && parts[2] == "crates":
+		id = parts[3]
+	case (host == "crates.io" || host == "static.crates.io") && len(parts) >= 2 && parts[0] == "crates":
+		id = parts[1]
+	default:
+		return
+	}
+	ok = true
+	return
+}
+
+// Latest finds the newest stable version of a crate
+func (cr *Crates) Latest(ctx context.Context, c *Client, id string) (r Release, err error) {
+	base := cr.BaseURL
+	if len(base) == 0 {
+		base = "https://crates.io"
+	}
+	var crate struct {
+		Crate struct {
+			MaxStableVersion string `json:"max_stable_version"`
+			MaxVersion       string `json:"max_version"`
+		} `json:"crate"`
+	}
+	if err = c.getJSON(ctx, base+"/api/v1/crates/"+id, nil, &crate); err != nil {
+		return
+	}
+	r.Version = crate.Crate.MaxStableVersion
+	if len(r.Version) == 0 {
+		r.Version = crate.Crate.MaxVersion
+	}
+	if len(r.Version) == 0 {
+		err = ErrNoRelease
+		return
+	}
+	r.URL = base + "/api/v1/crates/" + id + "/" + r.Version + "/download"
+	return
+}
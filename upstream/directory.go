@@ -0,0 +1,97 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package upstream
+
+import (
+	"context"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var (
+	// archivePattern splits an archive filename into its name, version and extension
+	archivePattern = regexp.MustCompile(`^(.+?)[-_]v?([0-9][0-9A-Za-z._]*?)\.(tar\.(?:gz|bz2|xz|zst|lz)|tgz|tbz2|zip)$`)
+	// hrefPattern finds the links in an HTML directory listing
+	hrefPattern = regexp.MustCompile(`(?i)href\s*=\s*["']([^"']+)["']`)
+)
+
+// Directory finds releases by scanning the HTML listing of the directory containing a source
+//
+// This works for most plain HTTP(S) mirrors, like ftp.gnu.org and kernel.org.
+type Directory struct{}
+
+// Name identifies this Provider in reports
+func (d *Directory) Name() string {
+	return "directory"
+}
+
+// Match accepts any HTTP(S) URL of an archive with a version in its filename
+func (d *Directory) Match(URL string) (id string, ok bool) {
+	u, err := url.Parse(URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return
+	}
+	if !archivePattern.MatchString(path.Base(u.Path)) {
+		return
+	}
+	id = URL
+	ok = true
+	return
+}
+
+// Latest lists the parent directory of an archive and finds the newest archive with the same name
+func (d *Directory) Latest(ctx context.Context, c *Client, id string) (r Release, err error) {
+	u, err := url.Parse(id)
+	if err != nil {
+		return
+	}
+	match := archivePattern.FindStringSubmatch(path.Base(u.Path))
+	if match == nil {
+		err = ErrNoRelease
+		return
+	}
+	pattern := regexp.MustCompile(`^` + regexp.QuoteMeta(match[1]) + `[-_]v?([0-9][0-9A-Za-z._]*?)\.` + regexp.QuoteMeta(match[3]) + `$`)
+	u.Path = path.Dir(u.Path) + "/"
+	u.RawQuery = ""
+	u.Fragment = ""
+	body, err := c.get(ctx, u.String(), nil)
+	if err != nil {
+		return
+	}
+	var versions, links []string
+	for _, href := range hrefPattern.FindAllStringSubmatch(string(body), -1) {
+		link := strings.SplitN(href[1], "?", 2)[0]
+		found := pattern.FindStringSubmatch(path.Base(link))
+		if found == nil || unstable(found[1]) {
+			continue
+		}
+		versions = append(versions, found[1])
+		links = append(links, link)
+	}
+	best := newest(versions)
+	if best < 0 {
+		err = ErrNoRelease
+		return
+	}
+	r.Version = versions[best]
+	if ref, perr := url.Parse(links[best]); perr == nil {
+		r.URL = u.ResolveReference(ref).String()
+	}
+	return
+}
@@ -0,0 +1,93 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package upstream
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// GitHub finds the releases and tags of projects hosted on GitHub
+type GitHub struct {
+	// BaseURL is the location of the API, "https://api.github.com" if empty
+	BaseURL string
+	// Token authenticates requests to avoid rate limiting, if set
+	Token string
+}
+
+// Name identifies this Provider in reports
+func (g *GitHub) Name() string {
+	return "github"
+}
+
+// Match gets the "owner/repo" of a GitHub project
+func (g *GitHub) Match(URL string) (id string, ok bool) {
+	host, parts := splitURL(URL)
+	if host != "github.com" || len(parts) < 2 {
+		return
+	}
+	id = parts[0] + "/" + strings.TrimSuffix(parts[1], ".git")
+	ok = true
+	return
+}
+
+// Latest finds the newest release of a project, falling back to tags if there are no releases
+func (g *GitHub) Latest(ctx context.Context, c *Client, id string) (r Release, err error) {
+	base := g.BaseURL
+	if len(base) == 0 {
+		base = "https://api.github.com"
+	}
+	header := http.Header{}
+	header.Set("Accept", "application/vnd.github.v3+json")
+	if len(g.Token) > 0 {
+		header.Set("Authorization", "token "+g.Token)
+	}
+	name := id[strings.Index(id, "/")+1:]
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	err = c.getJSON(ctx, base+"/repos/"+id+"/releases/latest", header, &release)
+	if err == nil {
+		if r.Version = cleanTag(release.TagName, name); len(r.Version) > 0 {
+			r.URL = "https://github.com/" + id + "/archive/" + release.TagName + ".tar.gz"
+			return
+		}
+	} else if !isNotFound(err) {
+		return
+	}
+	var tags []struct {
+		Name string `json:"name"`
+	}
+	if err = c.getJSON(ctx, base+"/repos/"+id+"/tags?per_page=100", header, &tags); err != nil {
+		return
+	}
+	versions := make([]string, len(tags))
+	for i, tag := range tags {
+		if v := cleanTag(tag.Name, name); !unstable(v) {
+			versions[i] = v
+		}
+	}
+	best := newest(versions)
+	if best < 0 {
+		err = ErrNoRelease
+		return
+	}
+	r.Version = versions[best]
+	r.URL = "https://github.com/" + id + "/archive/" + tags[best].Name + ".tar.gz"
+	return
+}
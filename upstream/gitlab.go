@@ -0,0 +1,103 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package upstream
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+// GitLab finds the releases and tags of projects hosted on GitLab instances
+type GitLab struct {
+	// BaseURL is the location of the instance, taken from the project URL if empty
+	BaseURL string
+}
+
+// Name identifies this Provider in reports
+func (g *GitLab) Name() string {
+	return "gitlab"
+}
+
+// Match gets the "host/group/project" of a project on gitlab.com or a "gitlab." instance
+func (g *GitLab) Match(URL string) (id string, ok bool) {
+	host, parts := splitURL(URL)
+	if host != "gitlab.com" && !strings.HasPrefix(host, "gitlab.") {
+		return
+	}
+	// Everything after "/-/" is a page within the project
+	for i, part := range parts {
+		if part == "-" {
+			parts = parts[:i]
+			break
+		}
+	}
+	if len(parts) < 2 {
+		return
+	}
+	parts[len(parts)-1] = strings.TrimSuffix(parts[len(parts)-1], ".git")
+	id = host + "/" + strings.Join(parts, "/")
+	ok = true
+	return
+}
+
+// Latest finds the newest release of a project, falling back to tags if there are no releases
+func (g *GitLab) Latest(ctx context.Context, c *Client, id string) (r Release, err error) {
+	sep := strings.Index(id, "/")
+	project := id[sep+1:]
+	name := project[strings.LastIndex(project, "/")+1:]
+	base := g.BaseURL
+	if len(base) == 0 {
+		base = "https://" + id[:sep]
+	}
+	api := base + "/api/v4/projects/" + url.PathEscape(project)
+	var tags []string
+	var releases []struct {
+		TagName string `json:"tag_name"`
+	}
+	if err = c.getJSON(ctx, api+"/releases", nil, &releases); err != nil && !isNotFound(err) {
+		return
+	}
+	for _, release := range releases {
+		tags = append(tags, release.TagName)
+	}
+	if len(tags) == 0 {
+		var found []struct {
+			Name string `json:"name"`
+		}
+		if err = c.getJSON(ctx, api+"/repository/tags", nil, &found); err != nil {
+			return
+		}
+		for _, tag := range found {
+			tags = append(tags, tag.Name)
+		}
+	}
+	versions := make([]string, len(tags))
+	for i, tag := range tags {
+		if v := cleanTag(tag, name); !unstable(v) {
+			versions[i] = v
+		}
+	}
+	best := newest(versions)
+	if best < 0 {
+		err = ErrNoRelease
+		return
+	}
+	r.Version = versions[best]
+	r.URL = base + "/" + project + "/-/archive/" + tags[best] + "/" + name + "-" + tags[best] + ".tar.gz"
+	return
+}
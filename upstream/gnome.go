@@ -0,0 +1,94 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package upstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// GNOME finds the releases of projects on the GNOME download server
+type GNOME struct {
+	// BaseURL is the location of the download server, "https://download.gnome.org" if empty
+	BaseURL string
+}
+
+// Name identifies this Provider in reports
+func (g *GNOME) Name() string {
+	return "gnome"
+}
+
+// Match gets the name of a project from its "sources" directory
+func (g *GNOME) Match(URL string) (id string, ok bool) {
+	host, parts := splitURL(URL)
+	if host != "download.gnome.org" && host != "ftp.gnome.org" {
+		return
+	}
+	for i, part := range parts {
+		if part == "sources" && i+1 < len(parts) {
+			id = parts[i+1]
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+// Latest finds the newest stable version of a project from its "cache.json" listing
+//
+// The listing is an array of a format number, the files for each version, the
+// list of versions, and the "LATEST-IS" markers.
+func (g *GNOME) Latest(ctx context.Context, c *Client, id string) (r Release, err error) {
+	base := g.BaseURL
+	if len(base) == 0 {
+		base = "https://download.gnome.org"
+	}
+	dir := base + "/sources/" + id + "/"
+	var cache []json.RawMessage
+	if err = c.getJSON(ctx, dir+"cache.json", nil, &cache); err != nil {
+		return
+	}
+	if len(cache) < 3 {
+		err = fmt.Errorf("unexpected format of '%scache.json'", dir)
+		return
+	}
+	var files map[string]map[string]map[string]string
+	var listed map[string][]string
+	if err = json.Unmarshal(cache[1], &files); err != nil {
+		return
+	}
+	if err = json.Unmarshal(cache[2], &listed); err != nil {
+		return
+	}
+	versions := make([]string, len(listed[id]))
+	for i, v := range listed[id] {
+		if !unstable(v) {
+			versions[i] = v
+		}
+	}
+	best := newest(versions)
+	if best < 0 {
+		err = ErrNoRelease
+		return
+	}
+	r.Version = versions[best]
+	if file, ok := files[id][r.Version]["tar.xz"]; ok {
+		r.URL = dir + file
+	}
+	return
+}
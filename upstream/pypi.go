@@ -0,0 +1,84 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package upstream
+
+import (
+	"context"
+)
+
+// PyPI finds the releases of projects on the Python Package Index
+type PyPI struct {
+	// BaseURL is the location of the JSON API, "https://pypi.org" if empty
+	BaseURL string
+}
+
+// Name identifies this Provider in reports
+func (p *PyPI) Name() string {
+	return "pypi"
+}
+
+// Match gets the name of a project from a source archive or project page
+func (p *PyPI) Match(URL string) (id string, ok bool) {
+	host, parts := splitURL(URL)
+	switch host {
+	case "files.pythonhosted.org", "pypi.io", "pypi.python.org", "pypi.org":
+	default:
+		return
+	}
+	switch {
+	case len(parts) >= 4 && parts[0] == "packages" && parts[1] == "source":
+		id = parts[3]
+	case len(parts) >= 2 && parts[0] == "project":
+		id = parts[1]
+	default:
+		return
+	}
+	ok = true
+	return
+}
+
+// Latest finds the current release of a project and its source distribution
+func (p *PyPI) Latest(ctx context.Context, c *Client, id string) (r Release, err error) {
+	base := p.BaseURL
+	if len(base) == 0 {
+		base = "https://pypi.org"
+	}
+	var project struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		URLs []struct {
+			PackageType string `json:"packagetype"`
+			URL         string `json:"url"`
+		} `json:"urls"`
+	}
+	if err = c.getJSON(ctx, base+"/pypi/"+id+"/json", nil, &project); err != nil {
+		return
+	}
+	if len(project.Info.Version) == 0 {
+		err = ErrNoRelease
+		return
+	}
+	r.Version = project.Info.Version
+	for _, u := range project.URLs {
+		if u.PackageType == "sdist" {
+			r.URL = u.URL
+			break
+		}
+	}
+	return
+}
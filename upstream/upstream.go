@@ -0,0 +1,326 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package upstream
+
+import (
+	"context"
+	"dev.getsol.us/source/libypkg.git/spec"
+	"dev.getsol.us/source/libypkg.git/spec/shared/version"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrNoProvider indicates that none of the Providers recognize the sources or homepage of a package
+	ErrNoProvider = errors.New("no provider found for any source or homepage")
+	// ErrNoRelease indicates that a Provider found the project, but no usable release version
+	ErrNoRelease = errors.New("no releases found")
+)
+
+// Release is the newest version of a project found upstream
+type Release struct {
+	// Version is the version number, cleaned up to match what a package.yml would use
+	Version string
+	// URL is the location of the release archive, if known
+	URL string
+}
+
+// Provider finds the latest releases of projects hosted by a single service
+type Provider interface {
+	// Name identifies this Provider in reports
+	Name() string
+	// Match gets the ID of the project for a source or homepage URL, if this Provider hosts it
+	Match(URL string) (id string, ok bool)
+	// Latest finds the newest release of the project with this ID
+	Latest(ctx context.Context, c *Client, id string) (r Release, err error)
+}
+
+// DefaultProviders gets every supported Provider, in the order they are tried
+//
+// Directory is last because it matches any HTTP(S) archive.
+func DefaultProviders() []Provider {
+	return []Provider{
+		&GitHub{},
+		&GitLab{},
+		&PyPI{},
+		&Crates{},
+		&GNOME{},
+		&CPAN{},
+		&Directory{},
+	}
+}
+
+// splitURL gets the host and the non-empty path segments of a URL
+func splitURL(URL string) (host string, parts []string) {
+	u, err := url.Parse(URL)
+	if err != nil {
+		return
+	}
+	host = strings.ToLower(u.Host)
+	for _, part := range strings.Split(u.Path, "/") {
+		if len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	return
+}
+
+// Client makes the HTTP requests for Providers
+type Client struct {
+	// HTTP is used for every request, http.DefaultClient if nil
+	HTTP *http.Client
+	// UserAgent is sent with every request, if set
+	UserAgent string
+}
+
+// get requests the contents of a URL, with any extra headers
+func (c *Client) get(ctx context.Context, URL string, header http.Header) (body []byte, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, URL, nil)
+	if err != nil {
+		return
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	if len(c.UserAgent) > 0 {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	client := c.HTTP
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		err = &StatusError{URL: URL, Code: r.StatusCode}
+		return
+	}
+	return ioutil.ReadAll(r.Body)
+}
+
+// getJSON requests a URL and decodes the response as JSON into "v"
+func (c *Client) getJSON(ctx context.Context, URL string, header http.Header, v interface{}) error {
+	body, err := c.get(ctx, URL, header)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to decode '%s': %w", URL, err)
+	}
+	return nil
+}
+
+// StatusError is an unsuccessful HTTP response from a Provider
+type StatusError struct {
+	URL  string
+	Code int
+}
+
+// Error gets the URL and status of the failed request
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("failed to request '%s': %d %s", e.URL, e.Code, http.StatusText(e.Code))
+}
+
+// isNotFound checks if an error is a 404 from a Provider
+func isNotFound(err error) bool {
+	var serr *StatusError
+	return errors.As(err, &serr) && serr.Code == http.StatusNotFound
+}
+
+// Result is the outcome of checking a single package for updates
+type Result struct {
+	// Path is the location of the package.yml
+	Path string
+	// Name is the name of the package
+	Name string
+	// Version is the current version of the package
+	Version string
+	// Latest is the newest release found upstream
+	Latest Release
+	// Provider is the name of the Provider which found Latest
+	Provider string
+	// Err is the reason no release was found, if any
+	Err error
+}
+
+// Outdated checks if a newer release was found upstream
+func (r Result) Outdated() bool {
+	return r.Err == nil && len(r.Latest.Version) > 0 && version.Compare(r.Latest.Version, r.Version) > 0
+}
+
+// String summarizes this Result in a single line
+func (r Result) String() string {
+	if r.Err != nil {
+		return fmt.Sprintf("%s: %s (%s)", r.Name, r.Version, r.Err)
+	}
+	return fmt.Sprintf("%s: %s -> %s (%s)", r.Name, r.Version, r.Latest.Version, r.Provider)
+}
+
+// Results are the outcomes of checking several packages
+type Results []Result
+
+// Outdated gets only the Results with a newer release upstream
+func (rs Results) Outdated() (out Results) {
+	for _, r := range rs {
+		if r.Outdated() {
+			out = append(out, r)
+		}
+	}
+	return
+}
+
+// Failed gets only the Results which could not be checked
+func (rs Results) Failed() (out Results) {
+	for _, r := range rs {
+		if r.Err != nil {
+			out = append(out, r)
+		}
+	}
+	return
+}
+
+// String gets a report with one line per Result
+func (rs Results) String() string {
+	var lines []string
+	for _, r := range rs {
+		lines = append(lines, r.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Checker finds the latest upstream releases of packages
+type Checker struct {
+	Client
+	// Providers are tried in order for each source and then the homepage
+	Providers []Provider
+}
+
+// NewChecker creates a Checker with the DefaultProviders
+func NewChecker() *Checker {
+	return &Checker{
+		Client: Client{
+			UserAgent: "libypkg",
+		},
+		Providers: DefaultProviders(),
+	}
+}
+
+// Latest finds the newest upstream release for the package described by a Header
+//
+// Each source URL, then the homepage, is offered to every Provider in turn. The first
+// Provider to find a release wins.
+func (c *Checker) Latest(ctx context.Context, h spec.Header) (r Result) {
+	r.Name = h.Name
+	r.Version = h.Version
+	var URLs []string
+	for _, src := range h.Source {
+		URLs = append(URLs, src.URL)
+	}
+	if len(h.Homepage) > 0 {
+		URLs = append(URLs, h.Homepage)
+	}
+	r.Err = ErrNoProvider
+	for _, URL := range URLs {
+		for _, p := range c.Providers {
+			id, ok := p.Match(URL)
+			if !ok {
+				continue
+			}
+			latest, err := p.Latest(ctx, &c.Client, id)
+			if err != nil {
+				r.Err = fmt.Errorf("%s: %w", p.Name(), err)
+				continue
+			}
+			r.Latest = latest
+			r.Provider = p.Name()
+			r.Err = nil
+			return
+		}
+	}
+	return
+}
+
+// Check finds the newest upstream release for a single package.yml
+func (c *Checker) Check(ctx context.Context, path string) (r Result) {
+	h, err := spec.LoadHeader(path)
+	if err != nil {
+		r.Name = filepath.Base(filepath.Dir(path))
+		r.Err = err
+	} else {
+		r = c.Latest(ctx, h)
+	}
+	r.Path = path
+	return
+}
+
+// CheckTree finds the newest upstream release for every package.yml under a directory
+//
+// Hidden directories, like ".git", are skipped. Results are sorted by package name.
+func (c *Checker) CheckTree(ctx context.Context, root string, workers int) (rs Results, err error) {
+	var paths []string
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && path != root && strings.HasPrefix(info.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !info.IsDir() && info.Name() == "package.yml" {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	rs = make(Results, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				rs[i] = c.Check(ctx, paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].Name < rs[j].Name
+	})
+	return
+}
@@ -0,0 +1,211 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package upstream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newUpstream serves canned responses for every Provider
+func newUpstream(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	respond := func(pattern, body string) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, body)
+		})
+	}
+	respond("/repos/DataDrake/cuppa/releases/latest", `{"tag_name": "v1.1.3"}`)
+	mux.HandleFunc("/repos/golang/go/releases/latest", http.NotFound)
+	respond("/repos/golang/go/tags", `[{"name": "weekly.2012-03-27"}, {"name": "go1.9.7"}, {"name": "go1.16.3"}, {"name": "go1.17beta1"}, {"name": "go1.16.2"}]`)
+	mux.HandleFunc("/api/v4/projects/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/api/v4/projects/inkscape%2Finkscape/releases":
+			_, _ = io.WriteString(w, `[{"tag_name": "INKSCAPE_1_0_2"}, {"tag_name": "INKSCAPE_1_1"}]`)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	respond("/pypi/requests/json", `{"info": {"version": "2.25.1"}, "urls": [{"packagetype": "bdist_wheel", "url": "https://example.com/requests.whl"}, {"packagetype": "sdist", "url": "https://example.com/requests-2.25.1.tar.gz"}]}`)
+	respond("/api/v1/crates/ripgrep", `{"crate": {"max_stable_version": "12.1.1", "max_version": "13.0.0-beta"}}`)
+	respond("/sources/glib/cache.json", `[4, {"glib": {"2.66.8": {"tar.xz": "2.66/glib-2.66.8.tar.xz"}, "2.68.1": {"tar.xz": "2.68/glib-2.68.1.tar.xz"}, "2.69.0": {"tar.xz": "2.69/glib-2.69.0.tar.xz"}}}, {"glib": ["2.66.8", "2.68.1", "2.69.0.rc"]}, ["LATEST-IS-2.68.1"]]`)
+	respond("/v1/release/Try-Tiny", `{"version": "0.30", "download_url": "https://cpan.metacpan.org/authors/id/E/ET/ETHER/Try-Tiny-0.30.tar.gz"}`)
+	respond("/gnu/nano/", `<a href="nano-5.5.tar.xz">nano-5.5.tar.xz</a> <a href="nano-5.6.1.tar.xz">nano-5.6.1.tar.xz</a> <a href="nano-5.6.1.tar.xz.sig">sig</a> <a href="nano-5.10.tar.gz">other</a> <a href="nano-5.7rc1.tar.xz">rc</a>`)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// testProviders points every Provider at a test server
+func testProviders(base string) []Provider {
+	return []Provider{
+		&GitHub{BaseURL: base},
+		&GitLab{BaseURL: base},
+		&PyPI{BaseURL: base},
+		&Crates{BaseURL: base},
+		&GNOME{BaseURL: base},
+		&CPAN{BaseURL: base},
+		&Directory{},
+	}
+}
+
+func TestProviderMatch(t *testing.T) {
+	cases := []struct {
+		p        Provider
+		URL, id  string
+		expected bool
+	}{
+		{&GitHub{}, "https://github.com/DataDrake/cuppa/archive/v1.1.2.tar.gz", "DataDrake/cuppa", true},
+		{&GitHub{}, "https://github.com/golang/go.git", "golang/go", true},
+		{&GitHub{}, "https://gitlab.com/inkscape/inkscape", "", false},
+		{&GitLab{}, "https://gitlab.com/inkscape/inkscape/-/archive/INKSCAPE_1_0_2/inkscape-INKSCAPE_1_0_2.tar.gz", "gitlab.com/inkscape/inkscape", true},
+		{&GitLab{}, "https://gitlab.freedesktop.org/mesa/mesa.git", "gitlab.freedesktop.org/mesa/mesa", true},
+		{&GitLab{}, "https://example.com/mesa/mesa.git", "", false},
+		{&GitLab{}, "https://gitlab.gnome.org/GNOME/gnome-shell.git", "gitlab.gnome.org/GNOME/gnome-shell", true},
+		{&PyPI{}, "https://files.pythonhosted.org/packages/source/r/requests/requests-2.25.0.tar.gz", "requests", true},
+		{&PyPI{}, "https://pypi.org/project/requests/", "requests", true},
+		{&Crates{}, "https://static.crates.io/crates/ripgrep/ripgrep-12.1.0.crate", "ripgrep", true},
+		{&Crates{}, "https://crates.io/api/v1/crates/ripgrep/12.1.0/download", "ripgrep", true},
+		{&GNOME{}, "https://download.gnome.org/sources/glib/2.66/glib-2.66.8.tar.xz", "glib", true},
+		{&CPAN{}, "https://cpan.metacpan.org/authors/id/E/ET/ETHER/Try-Tiny-0.28.tar.gz", "Try-Tiny", true},
+		{&CPAN{}, "https://metacpan.org/release/Try-Tiny", "Try-Tiny", true},
+		{&Directory{}, "https://ftp.gnu.org/gnu/nano/nano-5.5.tar.xz", "https://ftp.gnu.org/gnu/nano/nano-5.5.tar.xz", true},
+		{&Directory{}, "https://example.com/", "", false},
+	}
+	for _, c := range cases {
+		id, ok := c.p.Match(c.URL)
+		if ok != c.expected {
+			t.Errorf("%s: expected match '%t' for '%s', found: %t", c.p.Name(), c.expected, c.URL, ok)
+			continue
+		}
+		if id != c.id {
+			t.Errorf("%s: expected '%s', found: %s", c.p.Name(), c.id, id)
+		}
+	}
+}
+
+func TestProviderLatest(t *testing.T) {
+	srv := newUpstream(t)
+	cases := []struct {
+		p            Provider
+		id           string
+		version, URL string
+	}{
+		{&GitHub{BaseURL: srv.URL}, "DataDrake/cuppa", "1.1.3", "https://github.com/DataDrake/cuppa/archive/v1.1.3.tar.gz"},
+		{&GitHub{BaseURL: srv.URL}, "golang/go", "1.16.3", "https://github.com/golang/go/archive/go1.16.3.tar.gz"},
+		{&GitLab{BaseURL: srv.URL}, "gitlab.com/inkscape/inkscape", "1.1", srv.URL + "/inkscape/inkscape/-/archive/INKSCAPE_1_1/inkscape-INKSCAPE_1_1.tar.gz"},
+		{&PyPI{BaseURL: srv.URL}, "requests", "2.25.1", "https://example.com/requests-2.25.1.tar.gz"},
+		{&Crates{BaseURL: srv.URL}, "ripgrep", "12.1.1", srv.URL + "/api/v1/crates/ripgrep/12.1.1/download"},
+		{&GNOME{BaseURL: srv.URL}, "glib", "2.68.1", srv.URL + "/sources/glib/2.68/glib-2.68.1.tar.xz"},
+		{&CPAN{BaseURL: srv.URL}, "Try-Tiny", "0.30", "https://cpan.metacpan.org/authors/id/E/ET/ETHER/Try-Tiny-0.30.tar.gz"},
+		{&Directory{}, srv.URL + "/gnu/nano/nano-5.5.tar.xz", "5.6.1", srv.URL + "/gnu/nano/nano-5.6.1.tar.xz"},
+	}
+	c := &Client{}
+	for _, tc := range cases {
+		r, err := tc.p.Latest(context.Background(), c, tc.id)
+		if err != nil {
+			t.Errorf("%s: expected no error, found: %s", tc.p.Name(), err)
+			continue
+		}
+		if r.Version != tc.version {
+			t.Errorf("%s: expected '%s', found: %s", tc.p.Name(), tc.version, r.Version)
+		}
+		if r.URL != tc.URL {
+			t.Errorf("%s: expected '%s', found: %s", tc.p.Name(), tc.URL, r.URL)
+		}
+	}
+}
+
+func TestProviderNotFound(t *testing.T) {
+	srv := newUpstream(t)
+	_, err := (&PyPI{BaseURL: srv.URL}).Latest(context.Background(), &Client{}, "missing")
+	if !isNotFound(err) {
+		t.Fatalf("expected not found error, found: %v", err)
+	}
+}
+
+func TestCleanTag(t *testing.T) {
+	cases := map[string]string{
+		"v1.0.1":            "1.0.1",
+		"go1.16.2":          "1.16.2",
+		"INKSCAPE_1_0_2":    "1.0.2",
+		"release-2.3":       "2.3",
+		"refs/tags/3.0":     "3.0",
+		"weekly.2012-03-27": "",
+		"latest":            "",
+	}
+	for tag, expected := range cases {
+		name := "go"
+		if tag == "INKSCAPE_1_0_2" {
+			name = "inkscape"
+		}
+		if v := cleanTag(tag, name); v != expected {
+			t.Errorf("expected '%s' for '%s', found: %s", expected, tag, v)
+		}
+	}
+}
+
+// writePackage writes a minimal package.yml to a new directory in the tree
+func writePackage(t *testing.T, root, name, version, source string) {
+	dir := filepath.Join(root, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	content := fmt.Sprintf("name       : %s\nversion    : %s\nrelease    : 1\nsource     :\n    - %s : HASH\n", name, version, source)
+	if err := ioutil.WriteFile(filepath.Join(dir, "package.yml"), []byte(content), 0644); err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+}
+
+func TestCheckTree(t *testing.T) {
+	srv := newUpstream(t)
+	root := t.TempDir()
+	writePackage(t, root, "nano", "5.5", srv.URL+"/gnu/nano/nano-5.5.tar.xz")
+	writePackage(t, root, "cuppa", "1.1.3", "https://github.com/DataDrake/cuppa/archive/v1.1.3.tar.gz")
+	writePackage(t, root, "unknown", "1.0", "file:///tmp/unknown-1.0.tar.gz")
+	writePackage(t, filepath.Join(root, ".git"), "hidden", "1.0", srv.URL+"/gnu/nano/nano-5.5.tar.xz")
+	c := NewChecker()
+	c.Providers = testProviders(srv.URL)
+	rs, err := c.CheckTree(context.Background(), root, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, found: %s", err)
+	}
+	if len(rs) != 3 {
+		t.Fatalf("expected 3 results, found: %d", len(rs))
+	}
+	if rs[0].Name != "cuppa" || rs[1].Name != "nano" || rs[2].Name != "unknown" {
+		t.Fatalf("expected results sorted by name, found:\n%s", rs)
+	}
+	outdated := rs.Outdated()
+	if len(outdated) != 1 || outdated[0].Name != "nano" {
+		t.Fatalf("expected only nano to be outdated, found:\n%s", outdated)
+	}
+	if p := outdated[0].Provider; p != "directory" {
+		t.Errorf("expected '%s', found: %s", "directory", p)
+	}
+	failed := rs.Failed()
+	if len(failed) != 1 || failed[0].Err != ErrNoProvider {
+		t.Fatalf("expected unknown to have no provider, found:\n%s", failed)
+	}
+}
@@ -0,0 +1,76 @@
+//
+// Copyright © 2021 Solus Project <copyright@getsol.us>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package upstream
+
+import (
+	"dev.getsol.us/source/libypkg.git/spec/shared/version"
+	"regexp"
+	"strings"
+)
+
+// tagPrefixes are commonly put before the version number in release tags
+var tagPrefixes = []string{"release-", "release_", "version-", "version", "rel-", "v"}
+
+// numeric matches versions with only digits between separators, like "1_2_3"
+var numeric = regexp.MustCompile(`^[0-9]+([-_][0-9]+)+$`)
+
+// cleanTag gets the version number from a release tag, or an empty string if there isn't one
+func cleanTag(tag, name string) string {
+	tag = strings.TrimPrefix(tag, "refs/tags/")
+	if len(name) > 0 && len(tag) > len(name) && strings.EqualFold(tag[:len(name)], name) {
+		tag = strings.TrimLeft(tag[len(name):], "-_")
+	}
+	lower := strings.ToLower(tag)
+	for _, prefix := range tagPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			tag = tag[len(prefix):]
+			break
+		}
+	}
+	if len(tag) == 0 || tag[0] < '0' || tag[0] > '9' {
+		return ""
+	}
+	if numeric.MatchString(tag) {
+		tag = strings.NewReplacer("_", ".", "-", ".").Replace(tag)
+	}
+	return tag
+}
+
+// newest gets the index of the newest version in a list, or -1 if the list is empty
+func newest(versions []string) (best int) {
+	best = -1
+	for i, v := range versions {
+		if len(v) == 0 {
+			continue
+		}
+		if best < 0 || version.Compare(v, versions[best]) > 0 {
+			best = i
+		}
+	}
+	return
+}
+
+// unstable checks if a version is marked as a pre-release
+func unstable(version string) bool {
+	version = strings.ToLower(version)
+	for _, mark := range []string{"alpha", "beta", "rc", "pre", "dev"} {
+		if strings.Contains(version, mark) {
+			return true
+		}
+	}
+	return false
+}